@@ -0,0 +1,46 @@
+package apikey
+
+import (
+	"strings"
+	"time"
+)
+
+// Scopes an API key can be granted, each unlocking a group of endpoints.
+const (
+	ScopeProfileRead    = "profile:read"
+	ScopeProfileWrite   = "profile:write"
+	ScopeCampaignsWrite = "campaigns:write"
+)
+
+// APIKey lets a user's backend call the API without logging in. Only a hash
+// of the key is stored, the key itself is shown once when it is created.
+type APIKey struct {
+	ID         int
+	UserID     int
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     string
+	LastUsedAt *time.Time
+	LastUsedIP string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (k APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+
+	return strings.Split(k.Scopes, ",")
+}
+
+func (k APIKey) HasScope(scope string) bool {
+	for _, granted := range k.ScopeList() {
+		if granted == scope {
+			return true
+		}
+	}
+
+	return false
+}
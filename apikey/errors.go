@@ -0,0 +1,12 @@
+package apikey
+
+import "backer/apperror"
+
+var (
+	ErrAPIKeyNotFound  = apperror.NotFound("API key not found")
+	ErrInvalidAPIKey   = apperror.Unauthorized("Invalid API key")
+	ErrScopeNotGranted = apperror.Forbidden("API key has not been granted the scope this endpoint requires")
+	ErrNotAllowed      = apperror.Forbidden("This endpoint cannot be used with an API key")
+	ErrTooManyAPIKeys  = apperror.Conflict("API key limit reached, revoke an unused key first")
+	ErrEmailChange     = apperror.Forbidden("The email address cannot be changed with an API key")
+)
@@ -0,0 +1,40 @@
+package apikey
+
+import "time"
+
+type APIKeyFormatter struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Key        string     `json:"key,omitempty"`
+}
+
+// FormatAPIKey formats the key, key is only given right after creating it.
+func FormatAPIKey(apiKey APIKey, key string) APIKeyFormatter {
+	formatter := APIKeyFormatter{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Prefix:     apiKey.Prefix,
+		Scopes:     apiKey.ScopeList(),
+		LastUsedAt: apiKey.LastUsedAt,
+		LastUsedIP: apiKey.LastUsedIP,
+		CreatedAt:  apiKey.CreatedAt,
+		Key:        key,
+	}
+
+	return formatter
+}
+
+func FormatAPIKeys(apiKeys []APIKey) []APIKeyFormatter {
+	formatters := []APIKeyFormatter{}
+
+	for _, apiKey := range apiKeys {
+		formatters = append(formatters, FormatAPIKey(apiKey, ""))
+	}
+
+	return formatters
+}
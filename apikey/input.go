@@ -0,0 +1,14 @@
+package apikey
+
+import "backer/user"
+
+type CreateAPIKeyInput struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,dive,oneof=profile:read profile:write campaigns:write"`
+	User   user.User
+}
+
+type GetAPIKeyInput struct {
+	ID   int `uri:"id" binding:"required"`
+	User user.User
+}
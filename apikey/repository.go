@@ -0,0 +1,82 @@
+package apikey
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Save(apiKey APIKey) (APIKey, error)
+	FindByID(ID int) (APIKey, error)
+	FindByKeyHash(keyHash string) (APIKey, error)
+	FindByUserID(userID int) ([]APIKey, error)
+	Update(apiKey APIKey) (APIKey, error)
+	Delete(apiKey APIKey) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) Save(apiKey APIKey) (APIKey, error) {
+	if err := r.db.Create(&apiKey).Error; err != nil {
+		return apiKey, err
+	}
+
+	return apiKey, nil
+}
+
+func (r *repository) FindByID(ID int) (APIKey, error) {
+	var apiKey APIKey
+
+	err := r.db.Where("id = ?", ID).First(&apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apiKey, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return apiKey, err
+	}
+
+	return apiKey, nil
+}
+
+func (r *repository) FindByKeyHash(keyHash string) (APIKey, error) {
+	var apiKey APIKey
+
+	err := r.db.Where("key_hash = ?", keyHash).First(&apiKey).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apiKey, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return apiKey, err
+	}
+
+	return apiKey, nil
+}
+
+func (r *repository) FindByUserID(userID int) ([]APIKey, error) {
+	var apiKeys []APIKey
+
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+func (r *repository) Update(apiKey APIKey) (APIKey, error) {
+	if err := r.db.Save(&apiKey).Error; err != nil {
+		return apiKey, err
+	}
+
+	return apiKey, nil
+}
+
+func (r *repository) Delete(apiKey APIKey) error {
+	return r.db.Delete(&apiKey).Error
+}
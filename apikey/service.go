@@ -0,0 +1,150 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+const (
+	// keyPrefix marks API keys, so leaked ones are easy to recognise
+	keyPrefix = "bk_"
+	// shownPrefixLength is how much of the key stays visible to tell keys
+	// apart after creation
+	shownPrefixLength = 10
+
+	maxAPIKeysPerUser = 20
+	// touchInterval keeps the last used time accurate enough without
+	// writing on every request
+	touchInterval = time.Minute
+)
+
+type Service interface {
+	CreateAPIKey(input CreateAPIKeyInput) (APIKey, string, error)
+	GetAPIKeys(userID int) ([]APIKey, error)
+	RevokeAPIKey(input GetAPIKeyInput) (APIKey, error)
+	Authenticate(key string, ipAddress string) (APIKey, error)
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(repository Repository) *service {
+	return &service{repository}
+}
+
+// CreateAPIKey returns the new key record together with the key itself,
+// which cannot be recovered later.
+func (s *service) CreateAPIKey(input CreateAPIKeyInput) (APIKey, string, error) {
+	apiKeys, err := s.repository.FindByUserID(input.User.ID)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+
+	if len(apiKeys) >= maxAPIKeysPerUser {
+		return APIKey{}, "", ErrTooManyAPIKeys
+	}
+
+	keyInByte := make([]byte, 32)
+	if _, err := rand.Read(keyInByte); err != nil {
+		return APIKey{}, "", err
+	}
+
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(keyInByte)
+
+	apiKey := APIKey{
+		UserID:  input.User.ID,
+		Name:    input.Name,
+		Prefix:  key[:shownPrefixLength],
+		KeyHash: hashKey(key),
+		Scopes:  strings.Join(uniqueScopes(input.Scopes), ","),
+	}
+
+	newAPIKey, err := s.repository.Save(apiKey)
+	if err != nil {
+		return newAPIKey, "", err
+	}
+
+	return newAPIKey, key, nil
+}
+
+func (s *service) GetAPIKeys(userID int) ([]APIKey, error) {
+	apiKeys, err := s.repository.FindByUserID(userID)
+	if err != nil {
+		return apiKeys, err
+	}
+
+	return apiKeys, nil
+}
+
+func (s *service) RevokeAPIKey(input GetAPIKeyInput) (APIKey, error) {
+	apiKey, err := s.repository.FindByID(input.ID)
+	if err != nil {
+		return apiKey, err
+	}
+
+	// Someone else's key does not exist as far as the user can tell
+	if apiKey.UserID != input.User.ID {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+
+	if err := s.repository.Delete(apiKey); err != nil {
+		return apiKey, err
+	}
+
+	return apiKey, nil
+}
+
+// Authenticate finds the key a request is made with and records its use.
+func (s *service) Authenticate(key string, ipAddress string) (APIKey, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.repository.FindByKeyHash(hashKey(key))
+	if err != nil {
+		return apiKey, err
+	}
+
+	now := time.Now()
+
+	if apiKey.LastUsedAt != nil && now.Sub(*apiKey.LastUsedAt) < touchInterval && apiKey.LastUsedIP == ipAddress {
+		return apiKey, nil
+	}
+
+	apiKey.LastUsedAt = &now
+	apiKey.LastUsedIP = ipAddress
+
+	updatedAPIKey, err := s.repository.Update(apiKey)
+	if err != nil {
+		return updatedAPIKey, err
+	}
+
+	return updatedAPIKey, nil
+}
+
+// hashKey hashes keys for storage. They are random enough not to need a slow
+// password hash, which would be too slow to run on every request anyway.
+func hashKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(hash[:])
+}
+
+func uniqueScopes(scopes []string) []string {
+	seen := map[string]bool{}
+
+	var unique []string
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,197 @@
+package apikey
+
+import (
+	"backer/user"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeRepository keeps API keys in memory and counts the updates, so tests
+// can tell whether a use was recorded.
+type fakeRepository struct {
+	apiKeys map[int]APIKey
+	nextID  int
+	updates int
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{apiKeys: map[int]APIKey{}, nextID: 1}
+}
+
+func (r *fakeRepository) Save(apiKey APIKey) (APIKey, error) {
+	apiKey.ID = r.nextID
+	r.nextID++
+	r.apiKeys[apiKey.ID] = apiKey
+
+	return apiKey, nil
+}
+
+func (r *fakeRepository) FindByID(ID int) (APIKey, error) {
+	apiKey, ok := r.apiKeys[ID]
+	if !ok {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+
+	return apiKey, nil
+}
+
+func (r *fakeRepository) FindByKeyHash(keyHash string) (APIKey, error) {
+	for _, apiKey := range r.apiKeys {
+		if apiKey.KeyHash == keyHash {
+			return apiKey, nil
+		}
+	}
+
+	return APIKey{}, ErrInvalidAPIKey
+}
+
+func (r *fakeRepository) FindByUserID(userID int) ([]APIKey, error) {
+	var apiKeys []APIKey
+	for _, apiKey := range r.apiKeys {
+		if apiKey.UserID == userID {
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+
+	return apiKeys, nil
+}
+
+func (r *fakeRepository) Update(apiKey APIKey) (APIKey, error) {
+	r.updates++
+	r.apiKeys[apiKey.ID] = apiKey
+
+	return apiKey, nil
+}
+
+func (r *fakeRepository) Delete(apiKey APIKey) error {
+	delete(r.apiKeys, apiKey.ID)
+
+	return nil
+}
+
+func createKey(t *testing.T, s *service) (APIKey, string) {
+	t.Helper()
+
+	apiKey, key, err := s.CreateAPIKey(CreateAPIKeyInput{
+		Name:   "CI",
+		Scopes: []string{ScopeProfileRead, ScopeProfileRead, ScopeCampaignsWrite},
+		User:   user.User{ID: 7},
+	})
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	return apiKey, key
+}
+
+func TestAuthenticate(t *testing.T) {
+	repository := newFakeRepository()
+	s := NewService(repository)
+
+	apiKey, key := createKey(t, s)
+
+	if apiKey.KeyHash == key || apiKey.Prefix != key[:shownPrefixLength] {
+		t.Fatalf("CreateAPIKey() stored %+v, want only the hash and the prefix of the key", apiKey)
+	}
+
+	if apiKey.Scopes != "profile:read,campaigns:write" {
+		t.Errorf("Scopes = %q, want the scopes without duplicates", apiKey.Scopes)
+	}
+
+	authenticated, err := s.Authenticate(key, "203.0.113.1")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	if authenticated.ID != apiKey.ID || authenticated.UserID != 7 {
+		t.Errorf("Authenticate() = %+v, want the created key", authenticated)
+	}
+
+	if authenticated.LastUsedAt == nil || authenticated.LastUsedIP != "203.0.113.1" {
+		t.Errorf("Authenticate() = %+v, want the use recorded", authenticated)
+	}
+}
+
+func TestAuthenticateRejectsInvalidKeys(t *testing.T) {
+	s := NewService(newFakeRepository())
+
+	apiKey, key := createKey(t, s)
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"empty", ""},
+		{"without prefix", key[len(keyPrefix):]},
+		{"unknown", keyPrefix + "unknown"},
+		{"tampered", key + "x"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := s.Authenticate(test.key, "203.0.113.1"); !errors.Is(err, ErrInvalidAPIKey) {
+				t.Errorf("Authenticate() error = %v, want ErrInvalidAPIKey", err)
+			}
+		})
+	}
+
+	if _, err := s.RevokeAPIKey(GetAPIKeyInput{ID: apiKey.ID, User: user.User{ID: 7}}); err != nil {
+		t.Fatalf("RevokeAPIKey() error = %v", err)
+	}
+
+	if _, err := s.Authenticate(key, "203.0.113.1"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Authenticate() with a revoked key error = %v, want ErrInvalidAPIKey", err)
+	}
+}
+
+func TestAuthenticateRecordsUseSparingly(t *testing.T) {
+	recently := time.Now().Add(-touchInterval / 2)
+	longAgo := time.Now().Add(-2 * touchInterval)
+
+	tests := []struct {
+		name       string
+		lastUsedAt *time.Time
+		lastUsedIP string
+		wantUpdate bool
+	}{
+		{"never used", nil, "", true},
+		{"used recently from the same address", &recently, "203.0.113.1", false},
+		{"used recently from another address", &recently, "198.51.100.1", true},
+		{"used long ago", &longAgo, "203.0.113.1", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := newFakeRepository()
+			s := NewService(repository)
+
+			apiKey, key := createKey(t, s)
+			apiKey.LastUsedAt = test.lastUsedAt
+			apiKey.LastUsedIP = test.lastUsedIP
+			repository.apiKeys[apiKey.ID] = apiKey
+
+			if _, err := s.Authenticate(key, "203.0.113.1"); err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+
+			if gotUpdate := repository.updates > 0; gotUpdate != test.wantUpdate {
+				t.Errorf("recorded the use = %v, want %v", gotUpdate, test.wantUpdate)
+			}
+		})
+	}
+}
+
+func TestRevokeAPIKeyOfAnotherUser(t *testing.T) {
+	s := NewService(newFakeRepository())
+
+	apiKey, key := createKey(t, s)
+
+	if _, err := s.RevokeAPIKey(GetAPIKeyInput{ID: apiKey.ID, User: user.User{ID: 8}}); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("RevokeAPIKey() error = %v, want ErrAPIKeyNotFound", err)
+	}
+
+	if _, err := s.Authenticate(key, "203.0.113.1"); err != nil {
+		t.Errorf("Authenticate() error = %v, want the key to still work", err)
+	}
+}
@@ -0,0 +1,121 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+type Code string
+
+const (
+	CodeNotFound     Code = "not_found"
+	CodeUnauthorized Code = "unauthorized"
+	CodeForbidden    Code = "forbidden"
+	CodeValidation   Code = "validation"
+	CodeConflict     Code = "conflict"
+	CodeRateLimited  Code = "rate_limited"
+	CodeInternal     Code = "internal"
+)
+
+// Error is a domain error carrying a machine-readable code which the
+// handler layer translates into an HTTP status.
+type Error struct {
+	Code    Code
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func Wrap(code Code, message string, err error) *Error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+func NotFound(message string) *Error {
+	return New(CodeNotFound, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(CodeForbidden, message)
+}
+
+func Validation(message string) *Error {
+	return New(CodeValidation, message)
+}
+
+func Conflict(message string) *Error {
+	return New(CodeConflict, message)
+}
+
+// InvalidInput wraps a request binding error, e.g. validator.ValidationErrors
+// or a malformed JSON body.
+func InvalidInput(err error) *Error {
+	return Wrap(CodeValidation, "Invalid input", err)
+}
+
+func RateLimited(message string) *Error {
+	return New(CodeRateLimited, message)
+}
+
+func Internal(err error) *Error {
+	return Wrap(CodeInternal, "Internal server error", err)
+}
+
+// CodeOf returns the code of the first *Error in err's chain, treating
+// anything else as an internal error.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	return CodeInternal
+}
+
+// MessageOf returns the client-facing message of err. Errors without a
+// code never leak their text, since they usually come from the database.
+func MessageOf(err error) string {
+	var e *Error
+	if errors.As(err, &e) && e.Code != CodeInternal {
+		return e.Message
+	}
+
+	return "Internal server error"
+}
+
+func HTTPStatus(code Code) int {
+	switch code {
+	case CodeNotFound:
+		return http.StatusNotFound
+	case CodeUnauthorized:
+		return http.StatusUnauthorized
+	case CodeForbidden:
+		return http.StatusForbidden
+	case CodeValidation:
+		return http.StatusUnprocessableEntity
+	case CodeConflict:
+		return http.StatusConflict
+	case CodeRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
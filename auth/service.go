@@ -1,14 +1,24 @@
 package auth
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
 type Service interface {
-	GenerateToken(userID int) (string, error)
+	GenerateToken(userID int, sessionID int) (string, error)
 	ValidateToken(token string) (*jwt.Token, error)
+	GenerateEmailToken(userID int, email string) (string, error)
+	ValidateEmailToken(token string) (int, string, error)
+	GenerateTwoFactorToken(userID int) (string, error)
+	ValidateTwoFactorToken(token string) (int, error)
+	GenerateOAuthState(provider string) (string, string, error)
+	ValidateOAuthState(state string, provider string, nonce string) error
 }
 
 type service struct {
@@ -20,9 +30,26 @@ func NewService() *service {
 
 var SECRET_KEY = []byte("BWABACKERSTARTUP_53cr3t_k3y")
 
-func (s *service) GenerateToken(userID int) (string, error) {
+const (
+	purposeEmailVerification = "email_verification"
+	emailTokenLifetime       = 24 * time.Hour
+
+	purposeTwoFactor       = "two_factor"
+	twoFactorTokenLifetime = 5 * time.Minute
+
+	purposeOAuthState  = "oauth_state"
+	oauthStateLifetime = 10 * time.Minute
+)
+
+func (s *service) GenerateToken(userID int, sessionID int) (string, error) {
+	// With milliseconds, so a token issued in the same second as a password
+	// change is still told apart from the ones issued before it
+	issuedAt := float64(time.Now().UnixMilli()) / 1000
+
 	claim := jwt.MapClaims{
 		"user_id": userID,
+		"sid":     sessionID,
+		"iat":     issuedAt,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
@@ -35,7 +62,146 @@ func (s *service) GenerateToken(userID int) (string, error) {
 	return signedToken, nil
 }
 func (s *service) ValidateToken(encodedToken string) (*jwt.Token, error) {
-	token, err := jwt.Parse(encodedToken, func(t *jwt.Token) (interface{}, error) {
+	token, err := s.parse(encodedToken)
+	if err != nil {
+		return token, err
+	}
+
+	// Purpose-bound tokens, e.g. email verification links, are no sessions
+	if claim, ok := token.Claims.(jwt.MapClaims); ok && claim["purpose"] != nil {
+		return token, errors.New("Invalid token")
+	}
+
+	return token, nil
+}
+
+// GenerateEmailToken signs a token proving that its holder can read the
+// inbox of email, valid for 24 hours.
+func (s *service) GenerateEmailToken(userID int, email string) (string, error) {
+	claim := jwt.MapClaims{
+		"purpose": purposeEmailVerification,
+		"user_id": userID,
+		"email":   email,
+		"exp":     time.Now().Add(emailTokenLifetime).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
+
+	return token.SignedString(SECRET_KEY)
+}
+
+// ValidateEmailToken returns the user ID and email address an email token
+// was issued for.
+func (s *service) ValidateEmailToken(encodedToken string) (int, string, error) {
+	token, err := s.parse(encodedToken)
+	if err != nil {
+		return 0, "", err
+	}
+
+	claim, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claim["purpose"] != purposeEmailVerification {
+		return 0, "", errors.New("Invalid token")
+	}
+
+	userID, ok := claim["user_id"].(float64)
+	if !ok {
+		return 0, "", errors.New("Invalid token")
+	}
+
+	email, ok := claim["email"].(string)
+	if !ok {
+		return 0, "", errors.New("Invalid token")
+	}
+
+	return int(userID), email, nil
+}
+
+// GenerateTwoFactorToken signs a short-lived token proving that the user
+// passed the password step of a login, to be exchanged for a session token
+// together with a second factor.
+func (s *service) GenerateTwoFactorToken(userID int) (string, error) {
+	claim := jwt.MapClaims{
+		"purpose": purposeTwoFactor,
+		"user_id": userID,
+		"exp":     time.Now().Add(twoFactorTokenLifetime).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
+
+	return token.SignedString(SECRET_KEY)
+}
+
+func (s *service) ValidateTwoFactorToken(encodedToken string) (int, error) {
+	token, err := s.parse(encodedToken)
+	if err != nil {
+		return 0, err
+	}
+
+	claim, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claim["purpose"] != purposeTwoFactor {
+		return 0, errors.New("Invalid token")
+	}
+
+	userID, ok := claim["user_id"].(float64)
+	if !ok {
+		return 0, errors.New("Invalid token")
+	}
+
+	return int(userID), nil
+}
+
+// GenerateOAuthState signs the state parameter of a social login, proving on
+// the callback that the login was started here and for that provider. The
+// returned nonce has to be kept by the browser starting the login, so the
+// state cannot be completed in another one.
+func (s *service) GenerateOAuthState(provider string) (string, string, error) {
+	nonceInByte := make([]byte, 16)
+	if _, err := rand.Read(nonceInByte); err != nil {
+		return "", "", err
+	}
+
+	nonce := hex.EncodeToString(nonceInByte)
+
+	claim := jwt.MapClaims{
+		"purpose":  purposeOAuthState,
+		"provider": provider,
+		"nonce":    nonce,
+		"exp":      time.Now().Add(oauthStateLifetime).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
+
+	state, err := token.SignedString(SECRET_KEY)
+	if err != nil {
+		return "", "", err
+	}
+
+	return state, nonce, nil
+}
+
+// ValidateOAuthState checks the state is for the provider and was issued to
+// the browser holding nonce.
+func (s *service) ValidateOAuthState(state string, provider string, nonce string) error {
+	token, err := s.parse(state)
+	if err != nil {
+		return err
+	}
+
+	claim, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claim["purpose"] != purposeOAuthState || claim["provider"] != provider {
+		return errors.New("Invalid token")
+	}
+
+	stateNonce, _ := claim["nonce"].(string)
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(stateNonce), []byte(nonce)) != 1 {
+		return errors.New("Invalid token")
+	}
+
+	return nil
+}
+
+func (s *service) parse(encodedToken string) (*jwt.Token, error) {
+	return jwt.Parse(encodedToken, func(t *jwt.Token) (interface{}, error) {
 		_, ok := t.Method.(*jwt.SigningMethodHMAC)
 
 		if !ok {
@@ -44,9 +210,4 @@ func (s *service) ValidateToken(encodedToken string) (*jwt.Token, error) {
 
 		return []byte(SECRET_KEY), nil
 	})
-	if err != nil {
-		return token, err
-	}
-
-	return token, nil
 }
@@ -0,0 +1,54 @@
+package auth
+
+import "testing"
+
+func TestOAuthState(t *testing.T) {
+	s := NewService()
+
+	state, nonce, err := s.GenerateOAuthState("google")
+	if err != nil {
+		t.Fatalf("GenerateOAuthState() error = %v", err)
+	}
+
+	if err := s.ValidateOAuthState(state, "google", nonce); err != nil {
+		t.Errorf("ValidateOAuthState() error = %v, want nil", err)
+	}
+
+	_, otherNonce, err := s.GenerateOAuthState("google")
+	if err != nil {
+		t.Fatalf("GenerateOAuthState() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		state    string
+		provider string
+		nonce    string
+	}{
+		{"other provider", state, "github", nonce},
+		{"nonce of another login", state, "google", otherNonce},
+		{"no nonce", state, "google", ""},
+		{"tampered state", state + "x", "google", nonce},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := s.ValidateOAuthState(test.state, test.provider, test.nonce); err == nil {
+				t.Error("ValidateOAuthState() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestValidateTokenRejectsPurposeBoundTokens(t *testing.T) {
+	s := NewService()
+
+	state, _, err := s.GenerateOAuthState("google")
+	if err != nil {
+		t.Fatalf("GenerateOAuthState() error = %v", err)
+	}
+
+	if _, err := s.ValidateToken(state); err == nil {
+		t.Error("ValidateToken() accepted an OAuth state as a session token")
+	}
+}
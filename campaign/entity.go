@@ -3,6 +3,8 @@ package campaign
 import (
 	"backer/user"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Campaign struct {
@@ -16,10 +18,17 @@ type Campaign struct {
 	GoalAmount       int
 	CurrentAmount    int
 	Slug             string
-	CreatedAt        time.Time
-	UpdatedAt        time.Time
-	CampaignImages   []CampaignImage
-	User             user.User
+	ArchivedAt       *time.Time
+	// LastEditedAt and MaterialChanges sum up the revisions, so showing the
+	// campaign does not need to load them
+	LastEditedAt    *time.Time
+	MaterialChanges []string `gorm:"serializer:json"`
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt
+	CampaignImages  []CampaignImage
+	CampaignFAQs    []CampaignFAQ
+	User            user.User
 }
 
 type CampaignImage struct {
@@ -27,6 +36,40 @@ type CampaignImage struct {
 	CampaignID int
 	FileName   string
 	IsPrimary  int
+	Position   int
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt
+}
+
+// CampaignFAQ is a question and answer the owner lists on the campaign page,
+// in the order of Position.
+type CampaignFAQ struct {
+	ID         int
+	CampaignID int
+	Question   string
+	Answer     string
+	Position   int
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 }
+
+// CampaignRevision records who changed which fields of a campaign and when.
+// A revision is material when it changes what backers pledged for, after
+// the campaign got its first backer.
+type CampaignRevision struct {
+	ID         int
+	CampaignID int
+	UserID     int
+	Changes    []FieldChange `gorm:"serializer:json"`
+	IsMaterial bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	User       user.User
+}
+
+type FieldChange struct {
+	Field    string      `json:"field"`
+	OldValue interface{} `json:"old_value"`
+	NewValue interface{} `json:"new_value"`
+}
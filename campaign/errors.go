@@ -0,0 +1,16 @@
+package campaign
+
+import "backer/apperror"
+
+var (
+	ErrCampaignNotFound      = apperror.NotFound("Campaign not found")
+	ErrCampaignImageNotFound = apperror.NotFound("Campaign image not found")
+	ErrCampaignFAQNotFound   = apperror.NotFound("Campaign FAQ not found")
+	ErrNotCampaignOwner      = apperror.Forbidden("Not an owner of the campaign")
+	ErrInvalidImageOrder     = apperror.Validation("Image order must list every image of the campaign exactly once")
+	ErrInvalidFAQOrder       = apperror.Validation("FAQ order must list every FAQ of the campaign exactly once")
+	ErrCampaignHasBackers    = apperror.Conflict("Campaign has backers and cannot be deleted, archive it instead")
+	ErrCampaignArchived      = apperror.Conflict("Campaign is archived")
+	ErrCampaignNotArchived   = apperror.Conflict("Campaign is not archived")
+	ErrGoalAmountLocked      = apperror.Conflict("Goal amount cannot be changed once the campaign has backers")
+)
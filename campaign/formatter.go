@@ -1,62 +1,71 @@
 package campaign
 
 import (
+	"backer/storage"
+	"backer/upload"
 	"strings"
+	"time"
 )
 
 type CampaignFormatter struct {
-	ID               int    `json:"id"`
-	UserID           int    `json:"user_id"`
-	Name             string `json:"name"`
-	ShortDescription string `json:"short_description"`
-	ImageURL         string `json:"image_url"`
-	GoalAmount       int    `json:"goal_amount"`
-	CurrentAmount    int    `json:"current_amount"`
-	Slug             string `json:"slug"`
+	ID               int               `json:"id"`
+	UserID           int               `json:"user_id"`
+	Name             string            `json:"name"`
+	ShortDescription string            `json:"short_description"`
+	ImageURL         string            `json:"image_url"`
+	ImageRenditions  map[string]string `json:"image_renditions"`
+	GoalAmount       int               `json:"goal_amount"`
+	CurrentAmount    int               `json:"current_amount"`
+	Slug             string            `json:"slug"`
+	IsArchived       bool              `json:"is_archived"`
 }
 
-func FormatCampaign(campaign Campaign) CampaignFormatter {
+func FormatCampaign(campaign Campaign, urls *storage.URLBuilder) CampaignFormatter {
 	formatter := CampaignFormatter{
 		ID:               campaign.ID,
 		UserID:           campaign.UserID,
 		Name:             campaign.Name,
-		ImageURL:         "",
+		ImageURL:         urls.CampaignImageURL(primaryImageFileName(campaign)),
+		ImageRenditions:  upload.RenditionURLs(primaryImageFileName(campaign), urls.CampaignImageURL),
 		ShortDescription: campaign.ShortDescription,
 		GoalAmount:       campaign.GoalAmount,
 		CurrentAmount:    campaign.CurrentAmount,
 		Slug:             campaign.Slug,
-	}
-
-	if len(campaign.CampaignImages) > 0 {
-		formatter.ImageURL = campaign.CampaignImages[0].FileName
+		IsArchived:       campaign.ArchivedAt != nil,
 	}
 
 	return formatter
 }
 
-func FormatCampaigns(campaigns []Campaign) []CampaignFormatter {
+func FormatCampaigns(campaigns []Campaign, urls *storage.URLBuilder) []CampaignFormatter {
 	formatters := []CampaignFormatter{}
 
 	for _, campaign := range campaigns {
-		formatters = append(formatters, FormatCampaign(campaign))
+		formatters = append(formatters, FormatCampaign(campaign, urls))
 	}
 
 	return formatters
 }
 
 type CampaignDetailFormatter struct {
-	ID               int                      `json:"id"`
-	Name             string                   `json:"name"`
-	ShortDescription string                   `json:"short_description"`
-	Description      string                   `json:"description"`
-	ImageURL         string                   `json:"image_url"`
-	GoalAmount       int                      `json:"goal_amount"`
-	CurrentAmount    int                      `json:"current_amount"`
-	UserID           int                      `json:"user_id"`
-	Slug             string                   `json:"slug"`
-	Perks            []string                 `json:"perks"`
-	User             CampaignUserFormatter    `json:"user"`
-	Images           []CampaignImageFormatter `json:"images"`
+	ID                 int                      `json:"id"`
+	Name               string                   `json:"name"`
+	ShortDescription   string                   `json:"short_description"`
+	Description        string                   `json:"description"`
+	ImageURL           string                   `json:"image_url"`
+	ImageRenditions    map[string]string        `json:"image_renditions"`
+	GoalAmount         int                      `json:"goal_amount"`
+	CurrentAmount      int                      `json:"current_amount"`
+	UserID             int                      `json:"user_id"`
+	Slug               string                   `json:"slug"`
+	IsArchived         bool                     `json:"is_archived"`
+	LastEditedAt       *time.Time               `json:"last_edited_at"`
+	HasMaterialChanges bool                     `json:"has_material_changes"`
+	MaterialChanges    []string                 `json:"material_changes"`
+	Perks              []string                 `json:"perks"`
+	User               CampaignUserFormatter    `json:"user"`
+	Images             []CampaignImageFormatter `json:"images"`
+	FAQs               []CampaignFAQFormatter   `json:"faqs"`
 }
 type CampaignUserFormatter struct {
 	Name     string `json:"name"`
@@ -64,51 +73,147 @@ type CampaignUserFormatter struct {
 }
 
 type CampaignImageFormatter struct {
-	ImageURL  string `json:"image_url"`
-	IsPrimary bool   `json:"is_primary"`
+	ID         int               `json:"id"`
+	ImageURL   string            `json:"image_url"`
+	Renditions map[string]string `json:"renditions"`
+	IsPrimary  bool              `json:"is_primary"`
+	Position   int               `json:"position"`
 }
 
-func FormatCampaignDetail(campaign Campaign) CampaignDetailFormatter {
+func FormatCampaignDetail(campaign Campaign, urls *storage.URLBuilder) CampaignDetailFormatter {
 	var perks []string
 
 	for _, perk := range strings.Split(campaign.Perks, ",") {
 		perks = append(perks, strings.TrimSpace(perk))
 	}
 
-	images := []CampaignImageFormatter{}
+	images := FormatCampaignImages(campaign.CampaignImages, urls)
 
-	for _, image := range campaign.CampaignImages {
-		isPrimary := false
-		if image.IsPrimary == 1 {
-			isPrimary = true
-		}
-		images = append(images, CampaignImageFormatter{
-			ImageURL:  image.FileName,
-			IsPrimary: isPrimary,
-		})
+	materialChanges := campaign.MaterialChanges
+	if materialChanges == nil {
+		materialChanges = []string{}
 	}
 
 	formatter := CampaignDetailFormatter{
-		ID:               campaign.ID,
-		Name:             campaign.Name,
-		ShortDescription: campaign.ShortDescription,
-		Description:      campaign.Description,
-		ImageURL:         "",
-		GoalAmount:       campaign.GoalAmount,
-		CurrentAmount:    campaign.CurrentAmount,
-		UserID:           campaign.UserID,
-		Slug:             campaign.Slug,
-		Perks:            perks,
+		ID:                 campaign.ID,
+		Name:               campaign.Name,
+		ShortDescription:   campaign.ShortDescription,
+		Description:        campaign.Description,
+		ImageURL:           urls.CampaignImageURL(primaryImageFileName(campaign)),
+		ImageRenditions:    upload.RenditionURLs(primaryImageFileName(campaign), urls.CampaignImageURL),
+		GoalAmount:         campaign.GoalAmount,
+		CurrentAmount:      campaign.CurrentAmount,
+		UserID:             campaign.UserID,
+		Slug:               campaign.Slug,
+		IsArchived:         campaign.ArchivedAt != nil,
+		LastEditedAt:       campaign.LastEditedAt,
+		HasMaterialChanges: len(materialChanges) > 0,
+		MaterialChanges:    materialChanges,
+		Perks:              perks,
 		User: CampaignUserFormatter{
 			Name:     campaign.User.Name,
-			ImageURL: campaign.User.AvatarFileName,
+			ImageURL: urls.AvatarURL(campaign.User.AvatarFileName),
 		},
 		Images: images,
+		FAQs:   FormatCampaignFAQs(campaign.CampaignFAQs),
+	}
+
+	return formatter
+}
+
+func FormatCampaignImage(image CampaignImage, urls *storage.URLBuilder) CampaignImageFormatter {
+	isPrimary := false
+	if image.IsPrimary == 1 {
+		isPrimary = true
+	}
+
+	formatter := CampaignImageFormatter{
+		ID:         image.ID,
+		ImageURL:   urls.URL(image.FileName),
+		Renditions: upload.RenditionURLs(image.FileName, urls.URL),
+		IsPrimary:  isPrimary,
+		Position:   image.Position,
+	}
+
+	return formatter
+}
+
+func FormatCampaignImages(images []CampaignImage, urls *storage.URLBuilder) []CampaignImageFormatter {
+	formatters := []CampaignImageFormatter{}
+
+	for _, image := range images {
+		formatters = append(formatters, FormatCampaignImage(image, urls))
+	}
+
+	return formatters
+}
+
+func primaryImageFileName(campaign Campaign) string {
+	for _, image := range campaign.CampaignImages {
+		if image.IsPrimary == 1 {
+			return image.FileName
+		}
+	}
+
+	return ""
+}
+
+type CampaignFAQFormatter struct {
+	ID       int    `json:"id"`
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
+	Position int    `json:"position"`
+}
+
+func FormatCampaignFAQ(campaignFAQ CampaignFAQ) CampaignFAQFormatter {
+	formatter := CampaignFAQFormatter{
+		ID:       campaignFAQ.ID,
+		Question: campaignFAQ.Question,
+		Answer:   campaignFAQ.Answer,
+		Position: campaignFAQ.Position,
+	}
+
+	return formatter
+}
+
+func FormatCampaignFAQs(campaignFAQs []CampaignFAQ) []CampaignFAQFormatter {
+	formatters := []CampaignFAQFormatter{}
+
+	for _, campaignFAQ := range campaignFAQs {
+		formatters = append(formatters, FormatCampaignFAQ(campaignFAQ))
 	}
 
-	if len(campaign.CampaignImages) > 0 {
-		formatter.ImageURL = campaign.CampaignImages[0].FileName
+	return formatters
+}
+
+type CampaignRevisionFormatter struct {
+	ID         int           `json:"id"`
+	UserID     int           `json:"user_id"`
+	UserName   string        `json:"user_name"`
+	Changes    []FieldChange `json:"changes"`
+	IsMaterial bool          `json:"is_material"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+func FormatCampaignRevision(revision CampaignRevision) CampaignRevisionFormatter {
+	formatter := CampaignRevisionFormatter{
+		ID:         revision.ID,
+		UserID:     revision.UserID,
+		UserName:   revision.User.Name,
+		Changes:    revision.Changes,
+		IsMaterial: revision.IsMaterial,
+		CreatedAt:  revision.CreatedAt,
 	}
 
 	return formatter
 }
+
+func FormatCampaignRevisions(revisions []CampaignRevision) []CampaignRevisionFormatter {
+	formatters := []CampaignRevisionFormatter{}
+
+	for _, revision := range revisions {
+		formatters = append(formatters, FormatCampaignRevision(revision))
+	}
+
+	return formatters
+}
@@ -6,6 +6,12 @@ type GetCampaignInput struct {
 	ID int `uri:"id" binding:"required"`
 }
 
+// OwnedCampaignInput identifies a campaign the user acts on as its owner.
+type OwnedCampaignInput struct {
+	ID   int `uri:"id" binding:"required"`
+	User user.User
+}
+
 type CreateCampaignInput struct {
 	Name             string `json:"name" binding:"required"`
 	ShortDescription string `json:"short_description" binding:"required"`
@@ -15,8 +21,46 @@ type CreateCampaignInput struct {
 	User             user.User
 }
 
+// PatchCampaignInput holds the fields of a merge patch, a nil field is left
+// unchanged.
+type PatchCampaignInput struct {
+	Name             *string `json:"name" binding:"omitempty,min=1"`
+	ShortDescription *string `json:"short_description" binding:"omitempty,min=1"`
+	Description      *string `json:"description" binding:"omitempty,min=1"`
+	GoalAmount       *int    `json:"goal_amount" binding:"omitempty,gt=0"`
+	Perks            *string `json:"perks" binding:"omitempty,min=1"`
+	User             user.User
+}
+
+type GetCampaignImageInput struct {
+	ID   int `uri:"id" binding:"required"`
+	User user.User
+}
+
+type ReorderCampaignImagesInput struct {
+	ImageIDs []int `json:"image_ids" binding:"required,min=1"`
+	User     user.User
+}
+
 type CreateCampaignImageInput struct {
 	CampaignID int  `form:"campaign_id" binding:"required"`
 	IsPrimary  bool `form:"is_primary"`
 	User       user.User
 }
+
+type GetCampaignFAQInput struct {
+	CampaignID int `uri:"id" binding:"required"`
+	ID         int `uri:"faq_id" binding:"required"`
+	User       user.User
+}
+
+type CreateCampaignFAQInput struct {
+	Question string `json:"question" binding:"required,max=255"`
+	Answer   string `json:"answer" binding:"required,max=5000"`
+	User     user.User
+}
+
+type ReorderCampaignFAQsInput struct {
+	FAQIDs []int `json:"faq_ids" binding:"required,min=1"`
+	User   user.User
+}
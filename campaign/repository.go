@@ -1,6 +1,12 @@
 package campaign
 
-import "gorm.io/gorm"
+import (
+	"backer/transaction"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 type Repository interface {
 	FindAll() ([]Campaign, error)
@@ -8,8 +14,20 @@ type Repository interface {
 	FindByID(ID int) (Campaign, error)
 	Save(campaign Campaign) (Campaign, error)
 	Update(campaign Campaign) (Campaign, error)
+	UpdateWithRevision(campaign Campaign, revision CampaignRevision) (Campaign, error)
+	FindRevisionsByCampaignID(campaignID int) ([]CampaignRevision, error)
+	FindImageByID(ID int) (CampaignImage, error)
 	SaveImage(campaignImage CampaignImage) (CampaignImage, error)
-	MarkAllImagesAsNonPrimary(campaignID int) (bool, error)
+	DeleteImage(campaignImage CampaignImage) error
+	SetPrimaryImage(campaignImage CampaignImage) (CampaignImage, error)
+	UpdateImagePositions(campaignImages []CampaignImage) ([]CampaignImage, error)
+	FindFAQByID(ID int) (CampaignFAQ, error)
+	SaveFAQ(campaignFAQ CampaignFAQ) (CampaignFAQ, error)
+	UpdateFAQ(campaignFAQ CampaignFAQ) (CampaignFAQ, error)
+	UpdateFAQPositions(campaignFAQs []CampaignFAQ) ([]CampaignFAQ, error)
+	DeleteFAQ(campaignFAQ CampaignFAQ) error
+	Delete(campaign Campaign) error
+	CountPaidTransactions(campaignID int) (int64, error)
 }
 
 type repository struct {
@@ -23,6 +41,7 @@ func NewRepository(db *gorm.DB) *repository {
 func (r *repository) FindAll() ([]Campaign, error) {
 	var campaigns []Campaign
 	if err := r.db.
+		Where("archived_at IS NULL").
 		Preload("CampaignImages", "campaign_images.is_primary = 1").
 		Find(&campaigns).Error; err != nil {
 		return nil, err
@@ -46,11 +65,20 @@ func (r *repository) FindByUserID(userID int) ([]Campaign, error) {
 func (r *repository) FindByID(ID int) (Campaign, error) {
 	var campaign Campaign
 
-	if err := r.db.
+	err := r.db.
 		Where("id = ?", ID).
 		Preload("User").
-		Preload("CampaignImages").
-		Find(&campaign).Error; err != nil {
+		Preload("CampaignImages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("campaign_images.position, campaign_images.id")
+		}).
+		Preload("CampaignFAQs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("campaign_faqs.position, campaign_faqs.id")
+		}).
+		First(&campaign).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return campaign, ErrCampaignNotFound
+	}
+	if err != nil {
 		return campaign, err
 	}
 
@@ -73,21 +101,235 @@ func (r *repository) Update(campaign Campaign) (Campaign, error) {
 	return campaign, nil
 }
 
+// UpdateWithRevision saves the campaign together with the revision that
+// describes the change, so no edit goes unrecorded.
+func (r *repository) UpdateWithRevision(campaign Campaign, revision CampaignRevision) (Campaign, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&campaign).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&revision).Error
+	})
+	if err != nil {
+		return campaign, err
+	}
+
+	return campaign, nil
+}
+
+func (r *repository) FindRevisionsByCampaignID(campaignID int) ([]CampaignRevision, error) {
+	var revisions []CampaignRevision
+
+	if err := r.db.
+		Where("campaign_id = ?", campaignID).
+		Preload("User").
+		Order("id DESC").
+		Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (r *repository) FindImageByID(ID int) (CampaignImage, error) {
+	var campaignImage CampaignImage
+
+	err := r.db.Where("id = ?", ID).First(&campaignImage).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return campaignImage, ErrCampaignImageNotFound
+	}
+	if err != nil {
+		return campaignImage, err
+	}
+
+	return campaignImage, nil
+}
+
+// SaveImage adds the image at the end of the gallery. A primary image
+// replaces the current one. The campaign row stays locked until the image is
+// in, so parallel uploads neither share a position nor leave two primary
+// images.
 func (r *repository) SaveImage(campaignImage CampaignImage) (CampaignImage, error) {
-	if err := r.db.Create(&campaignImage).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", campaignImage.CampaignID).
+			First(&Campaign{}).Error; err != nil {
+			return err
+		}
+
+		if campaignImage.IsPrimary == 1 {
+			if err := tx.
+				Model(&CampaignImage{}).
+				Where("campaign_id = ?", campaignImage.CampaignID).
+				Update("is_primary", false).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.
+			Model(&CampaignImage{}).
+			Where("campaign_id = ?", campaignImage.CampaignID).
+			Select("COALESCE(MAX(position) + 1, 0)").
+			Scan(&campaignImage.Position).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&campaignImage).Error
+	})
+	if err != nil {
 		return campaignImage, err
 	}
 
 	return campaignImage, nil
 }
 
-func (r *repository) MarkAllImagesAsNonPrimary(campaignID int) (bool, error) {
+// DeleteImage deletes the image for good, its files are removed from the
+// storage too. Deleting the primary image promotes the first remaining one,
+// so the campaign keeps a cover.
+func (r *repository) DeleteImage(campaignImage CampaignImage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Delete(&campaignImage).Error; err != nil {
+			return err
+		}
+
+		if campaignImage.IsPrimary == 0 {
+			return nil
+		}
+
+		var nextImage CampaignImage
+
+		err := tx.
+			Where("campaign_id = ?", campaignImage.CampaignID).
+			Order("position, id").
+			First(&nextImage).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&nextImage).Update("is_primary", 1).Error
+	})
+}
+
+// SetPrimaryImage makes the image the only primary one of its campaign.
+func (r *repository) SetPrimaryImage(campaignImage CampaignImage) (CampaignImage, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Model(&CampaignImage{}).
+			Where("campaign_id = ?", campaignImage.CampaignID).
+			Update("is_primary", false).Error; err != nil {
+			return err
+		}
+
+		campaignImage.IsPrimary = 1
+
+		return tx.Save(&campaignImage).Error
+	})
+	if err != nil {
+		return campaignImage, err
+	}
+
+	return campaignImage, nil
+}
+
+// UpdateImagePositions saves the positions of the images all at once, so a
+// failure leaves the gallery in its previous order.
+func (r *repository) UpdateImagePositions(campaignImages []CampaignImage) ([]CampaignImage, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range campaignImages {
+			if err := tx.Model(&campaignImages[i]).Update("position", campaignImages[i].Position).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return campaignImages, nil
+}
+
+func (r *repository) FindFAQByID(ID int) (CampaignFAQ, error) {
+	var campaignFAQ CampaignFAQ
+
+	err := r.db.Where("id = ?", ID).First(&campaignFAQ).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return campaignFAQ, ErrCampaignFAQNotFound
+	}
+	if err != nil {
+		return campaignFAQ, err
+	}
+
+	return campaignFAQ, nil
+}
+
+func (r *repository) SaveFAQ(campaignFAQ CampaignFAQ) (CampaignFAQ, error) {
+	if err := r.db.Create(&campaignFAQ).Error; err != nil {
+		return campaignFAQ, err
+	}
+
+	return campaignFAQ, nil
+}
+
+func (r *repository) UpdateFAQ(campaignFAQ CampaignFAQ) (CampaignFAQ, error) {
+	if err := r.db.Save(&campaignFAQ).Error; err != nil {
+		return campaignFAQ, err
+	}
+
+	return campaignFAQ, nil
+}
+
+// UpdateFAQPositions saves the positions of the FAQs all at once, so a
+// failure leaves them in their previous order.
+func (r *repository) UpdateFAQPositions(campaignFAQs []CampaignFAQ) ([]CampaignFAQ, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range campaignFAQs {
+			if err := tx.Model(&campaignFAQs[i]).Update("position", campaignFAQs[i].Position).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return campaignFAQs, nil
+}
+
+func (r *repository) DeleteFAQ(campaignFAQ CampaignFAQ) error {
+	return r.db.Delete(&campaignFAQ).Error
+}
+
+// Delete soft deletes the campaign together with its images. Their files are
+// kept, so the campaign can still be restored.
+func (r *repository) Delete(campaign Campaign) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("campaign_id = ?", campaign.ID).Delete(&CampaignImage{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&campaign).Error
+	})
+}
+
+func (r *repository) CountPaidTransactions(campaignID int) (int64, error) {
+	var count int64
+
 	if err := r.db.
-		Model(&CampaignImage{}).
-		Where("campaign_id = ?", campaignID).
-		Update("is_primary", false).Error; err != nil {
-		return false, err
+		Model(&transaction.Transaction{}).
+		Where("campaign_id = ? AND status = ?", campaignID, transaction.StatusPaid).
+		Count(&count).Error; err != nil {
+		return 0, err
 	}
 
-	return true, nil
+	return count, nil
 }
@@ -1,8 +1,9 @@
 package campaign
 
 import (
-	"errors"
+	"backer/user"
 	"fmt"
+	"time"
 
 	"github.com/gosimple/slug"
 )
@@ -12,7 +13,19 @@ type Service interface {
 	GetCampaignByID(input GetCampaignInput) (Campaign, error)
 	CreateCampaign(input CreateCampaignInput) (Campaign, error)
 	UpdateCampaign(inputID GetCampaignInput, inputData CreateCampaignInput) (Campaign, error)
+	PatchCampaign(inputID GetCampaignInput, inputData PatchCampaignInput) (Campaign, error)
+	GetCampaignRevisions(input GetCampaignInput) ([]CampaignRevision, error)
 	CreateCampaignImage(input CreateCampaignImageInput, fileLocation string) (CampaignImage, error)
+	DeleteCampaignImage(input GetCampaignImageInput) (CampaignImage, error)
+	SetPrimaryCampaignImage(input GetCampaignImageInput) (CampaignImage, error)
+	ReorderCampaignImages(inputID GetCampaignInput, inputData ReorderCampaignImagesInput) ([]CampaignImage, error)
+	CreateCampaignFAQ(inputID GetCampaignInput, inputData CreateCampaignFAQInput) (CampaignFAQ, error)
+	UpdateCampaignFAQ(inputID GetCampaignFAQInput, inputData CreateCampaignFAQInput) (CampaignFAQ, error)
+	DeleteCampaignFAQ(input GetCampaignFAQInput) (CampaignFAQ, error)
+	ReorderCampaignFAQs(inputID GetCampaignInput, inputData ReorderCampaignFAQsInput) ([]CampaignFAQ, error)
+	DeleteCampaign(input OwnedCampaignInput) (Campaign, error)
+	ArchiveCampaign(input OwnedCampaignInput) (Campaign, error)
+	UnarchiveCampaign(input OwnedCampaignInput) (Campaign, error)
 }
 
 type service struct {
@@ -76,16 +89,125 @@ func (s *service) UpdateCampaign(inputID GetCampaignInput, inputData CreateCampa
 	}
 
 	if campaign.UserID != inputData.User.ID {
-		return campaign, errors.New("Not an owner of the campaign")
+		return campaign, ErrNotCampaignOwner
 	}
 
+	if campaign.ArchivedAt != nil {
+		return campaign, ErrCampaignArchived
+	}
+
+	if err := s.checkGoalAmountChange(campaign, inputData.GoalAmount); err != nil {
+		return campaign, err
+	}
+
+	before := campaign
+
 	campaign.Name = inputData.Name
 	campaign.ShortDescription = inputData.ShortDescription
 	campaign.Description = inputData.Description
 	campaign.Perks = inputData.Perks
 	campaign.GoalAmount = inputData.GoalAmount
 
-	updatedCampaign, err := s.repository.Update(campaign)
+	return s.saveChanges(before, campaign, inputData.User)
+}
+
+// PatchCampaign changes only the fields given in the patch.
+func (s *service) PatchCampaign(inputID GetCampaignInput, inputData PatchCampaignInput) (Campaign, error) {
+	campaign, err := s.repository.FindByID(inputID.ID)
+	if err != nil {
+		return campaign, err
+	}
+
+	if campaign.UserID != inputData.User.ID {
+		return campaign, ErrNotCampaignOwner
+	}
+
+	if campaign.ArchivedAt != nil {
+		return campaign, ErrCampaignArchived
+	}
+
+	before := campaign
+
+	if inputData.GoalAmount != nil {
+		if err := s.checkGoalAmountChange(campaign, *inputData.GoalAmount); err != nil {
+			return campaign, err
+		}
+
+		campaign.GoalAmount = *inputData.GoalAmount
+	}
+
+	if inputData.Name != nil {
+		campaign.Name = *inputData.Name
+	}
+
+	if inputData.ShortDescription != nil {
+		campaign.ShortDescription = *inputData.ShortDescription
+	}
+
+	if inputData.Description != nil {
+		campaign.Description = *inputData.Description
+	}
+
+	if inputData.Perks != nil {
+		campaign.Perks = *inputData.Perks
+	}
+
+	return s.saveChanges(before, campaign, inputData.User)
+}
+
+// materialFields are the fields backers pledge for. Changing them once the
+// campaign has backers makes the revision material.
+var materialFields = map[string]bool{
+	"description": true,
+	"goal_amount": true,
+	"perks":       true,
+}
+
+// saveChanges updates the campaign and records a revision of the fields that
+// differ from before.
+func (s *service) saveChanges(before Campaign, campaign Campaign, editor user.User) (Campaign, error) {
+	changes := diffCampaigns(before, campaign)
+	if len(changes) == 0 {
+		return campaign, nil
+	}
+
+	isMaterial := false
+	for _, change := range changes {
+		if materialFields[change.Field] {
+			isMaterial = true
+		}
+	}
+
+	// Nobody pledged for what the campaign said before its first backer
+	if isMaterial {
+		paidTransactions, err := s.repository.CountPaidTransactions(campaign.ID)
+		if err != nil {
+			return campaign, err
+		}
+
+		isMaterial = paidTransactions > 0
+	}
+
+	now := time.Now()
+	campaign.LastEditedAt = &now
+
+	// Fields that materially changed, in the order they first did
+	if isMaterial {
+		for _, change := range changes {
+			if materialFields[change.Field] && !containsString(campaign.MaterialChanges, change.Field) {
+				campaign.MaterialChanges = append(campaign.MaterialChanges, change.Field)
+			}
+		}
+	}
+
+	revision := CampaignRevision{
+		CampaignID: campaign.ID,
+		UserID:     editor.ID,
+		Changes:    changes,
+		IsMaterial: isMaterial,
+	}
+
+	updatedCampaign, err := s.repository.UpdateWithRevision(campaign, revision)
 	if err != nil {
 		return campaign, err
 	}
@@ -93,6 +215,67 @@ func (s *service) UpdateCampaign(inputID GetCampaignInput, inputData CreateCampa
 	return updatedCampaign, nil
 }
 
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func diffCampaigns(before Campaign, after Campaign) []FieldChange {
+	var changes []FieldChange
+
+	compare := func(field string, oldValue interface{}, newValue interface{}) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+
+	compare("name", before.Name, after.Name)
+	compare("short_description", before.ShortDescription, after.ShortDescription)
+	compare("description", before.Description, after.Description)
+	compare("goal_amount", before.GoalAmount, after.GoalAmount)
+	compare("perks", before.Perks, after.Perks)
+
+	return changes
+}
+
+func (s *service) GetCampaignRevisions(input GetCampaignInput) ([]CampaignRevision, error) {
+	campaign, err := s.repository.FindByID(input.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.repository.FindRevisionsByCampaignID(campaign.ID)
+	if err != nil {
+		return revisions, err
+	}
+
+	return revisions, nil
+}
+
+// checkGoalAmountChange keeps the goal backers pledged towards from moving
+// once the campaign is live.
+func (s *service) checkGoalAmountChange(campaign Campaign, goalAmount int) error {
+	if goalAmount == campaign.GoalAmount {
+		return nil
+	}
+
+	paidTransactions, err := s.repository.CountPaidTransactions(campaign.ID)
+	if err != nil {
+		return err
+	}
+
+	if paidTransactions > 0 {
+		return ErrGoalAmountLocked
+	}
+
+	return nil
+}
+
 func (s *service) CreateCampaignImage(input CreateCampaignImageInput, fileLocation string) (CampaignImage, error) {
 	campaign, err := s.repository.FindByID(input.CampaignID)
 	if err != nil {
@@ -100,17 +283,16 @@ func (s *service) CreateCampaignImage(input CreateCampaignImageInput, fileLocati
 	}
 
 	if campaign.UserID != input.User.ID {
-		return CampaignImage{}, errors.New("Not an owner of the campaign")
+		return CampaignImage{}, ErrNotCampaignOwner
+	}
+
+	if campaign.ArchivedAt != nil {
+		return CampaignImage{}, ErrCampaignArchived
 	}
 
 	isPrimary := 0
 	if input.IsPrimary {
 		isPrimary = 1
-
-		_, err := s.repository.MarkAllImagesAsNonPrimary(input.CampaignID)
-		if err != nil {
-			return CampaignImage{}, err
-		}
 	}
 
 	campaignImage := CampaignImage{
@@ -126,3 +308,310 @@ func (s *service) CreateCampaignImage(input CreateCampaignImageInput, fileLocati
 
 	return newCampaignImage, nil
 }
+
+func (s *service) DeleteCampaignImage(input GetCampaignImageInput) (CampaignImage, error) {
+	campaignImage, campaign, err := s.findOwnedImage(input)
+	if err != nil {
+		return campaignImage, err
+	}
+
+	if campaign.ArchivedAt != nil {
+		return campaignImage, ErrCampaignArchived
+	}
+
+	if err := s.repository.DeleteImage(campaignImage); err != nil {
+		return campaignImage, err
+	}
+
+	return campaignImage, nil
+}
+
+func (s *service) SetPrimaryCampaignImage(input GetCampaignImageInput) (CampaignImage, error) {
+	campaignImage, campaign, err := s.findOwnedImage(input)
+	if err != nil {
+		return campaignImage, err
+	}
+
+	if campaign.ArchivedAt != nil {
+		return campaignImage, ErrCampaignArchived
+	}
+
+	updatedImage, err := s.repository.SetPrimaryImage(campaignImage)
+	if err != nil {
+		return campaignImage, err
+	}
+
+	return updatedImage, nil
+}
+
+func (s *service) ReorderCampaignImages(inputID GetCampaignInput, inputData ReorderCampaignImagesInput) ([]CampaignImage, error) {
+	campaign, err := s.repository.FindByID(inputID.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if campaign.UserID != inputData.User.ID {
+		return nil, ErrNotCampaignOwner
+	}
+
+	if campaign.ArchivedAt != nil {
+		return nil, ErrCampaignArchived
+	}
+
+	images := make(map[int]CampaignImage)
+	for _, image := range campaign.CampaignImages {
+		images[image.ID] = image
+	}
+
+	if len(inputData.ImageIDs) != len(images) {
+		return nil, ErrInvalidImageOrder
+	}
+
+	var reorderedImages []CampaignImage
+
+	for position, imageID := range inputData.ImageIDs {
+		image, ok := images[imageID]
+		if !ok {
+			return nil, ErrInvalidImageOrder
+		}
+
+		delete(images, imageID)
+
+		image.Position = position
+		reorderedImages = append(reorderedImages, image)
+	}
+
+	reorderedImages, err = s.repository.UpdateImagePositions(reorderedImages)
+	if err != nil {
+		return nil, err
+	}
+
+	return reorderedImages, nil
+}
+
+func (s *service) CreateCampaignFAQ(inputID GetCampaignInput, inputData CreateCampaignFAQInput) (CampaignFAQ, error) {
+	campaign, err := s.findOwnedCampaign(OwnedCampaignInput{ID: inputID.ID, User: inputData.User})
+	if err != nil {
+		return CampaignFAQ{}, err
+	}
+
+	if campaign.ArchivedAt != nil {
+		return CampaignFAQ{}, ErrCampaignArchived
+	}
+
+	position := 0
+	for _, campaignFAQ := range campaign.CampaignFAQs {
+		if campaignFAQ.Position >= position {
+			position = campaignFAQ.Position + 1
+		}
+	}
+
+	campaignFAQ := CampaignFAQ{
+		CampaignID: campaign.ID,
+		Question:   inputData.Question,
+		Answer:     inputData.Answer,
+		Position:   position,
+	}
+
+	newCampaignFAQ, err := s.repository.SaveFAQ(campaignFAQ)
+	if err != nil {
+		return newCampaignFAQ, err
+	}
+
+	return newCampaignFAQ, nil
+}
+
+func (s *service) UpdateCampaignFAQ(inputID GetCampaignFAQInput, inputData CreateCampaignFAQInput) (CampaignFAQ, error) {
+	campaignFAQ, campaign, err := s.findOwnedFAQ(GetCampaignFAQInput{CampaignID: inputID.CampaignID, ID: inputID.ID, User: inputData.User})
+	if err != nil {
+		return campaignFAQ, err
+	}
+
+	if campaign.ArchivedAt != nil {
+		return campaignFAQ, ErrCampaignArchived
+	}
+
+	campaignFAQ.Question = inputData.Question
+	campaignFAQ.Answer = inputData.Answer
+
+	updatedCampaignFAQ, err := s.repository.UpdateFAQ(campaignFAQ)
+	if err != nil {
+		return updatedCampaignFAQ, err
+	}
+
+	return updatedCampaignFAQ, nil
+}
+
+func (s *service) DeleteCampaignFAQ(input GetCampaignFAQInput) (CampaignFAQ, error) {
+	campaignFAQ, campaign, err := s.findOwnedFAQ(input)
+	if err != nil {
+		return campaignFAQ, err
+	}
+
+	if campaign.ArchivedAt != nil {
+		return campaignFAQ, ErrCampaignArchived
+	}
+
+	if err := s.repository.DeleteFAQ(campaignFAQ); err != nil {
+		return campaignFAQ, err
+	}
+
+	return campaignFAQ, nil
+}
+
+func (s *service) ReorderCampaignFAQs(inputID GetCampaignInput, inputData ReorderCampaignFAQsInput) ([]CampaignFAQ, error) {
+	campaign, err := s.findOwnedCampaign(OwnedCampaignInput{ID: inputID.ID, User: inputData.User})
+	if err != nil {
+		return nil, err
+	}
+
+	if campaign.ArchivedAt != nil {
+		return nil, ErrCampaignArchived
+	}
+
+	campaignFAQs := make(map[int]CampaignFAQ)
+	for _, campaignFAQ := range campaign.CampaignFAQs {
+		campaignFAQs[campaignFAQ.ID] = campaignFAQ
+	}
+
+	if len(inputData.FAQIDs) != len(campaignFAQs) {
+		return nil, ErrInvalidFAQOrder
+	}
+
+	var reorderedFAQs []CampaignFAQ
+
+	for position, faqID := range inputData.FAQIDs {
+		campaignFAQ, ok := campaignFAQs[faqID]
+		if !ok {
+			return nil, ErrInvalidFAQOrder
+		}
+
+		delete(campaignFAQs, faqID)
+
+		campaignFAQ.Position = position
+		reorderedFAQs = append(reorderedFAQs, campaignFAQ)
+	}
+
+	reorderedFAQs, err = s.repository.UpdateFAQPositions(reorderedFAQs)
+	if err != nil {
+		return nil, err
+	}
+
+	return reorderedFAQs, nil
+}
+
+// DeleteCampaign soft deletes a campaign nobody has backed. Campaigns with
+// backers have to be archived instead, the pledges must stay traceable.
+func (s *service) DeleteCampaign(input OwnedCampaignInput) (Campaign, error) {
+	campaign, err := s.findOwnedCampaign(input)
+	if err != nil {
+		return campaign, err
+	}
+
+	paidTransactions, err := s.repository.CountPaidTransactions(campaign.ID)
+	if err != nil {
+		return campaign, err
+	}
+
+	if paidTransactions > 0 {
+		return campaign, ErrCampaignHasBackers
+	}
+
+	if err := s.repository.Delete(campaign); err != nil {
+		return campaign, err
+	}
+
+	return campaign, nil
+}
+
+// ArchiveCampaign hides the campaign from the listing and freezes it, while
+// it stays reachable for its backers.
+func (s *service) ArchiveCampaign(input OwnedCampaignInput) (Campaign, error) {
+	campaign, err := s.findOwnedCampaign(input)
+	if err != nil {
+		return campaign, err
+	}
+
+	if campaign.ArchivedAt != nil {
+		return campaign, ErrCampaignArchived
+	}
+
+	now := time.Now()
+	campaign.ArchivedAt = &now
+
+	updatedCampaign, err := s.repository.Update(campaign)
+	if err != nil {
+		return campaign, err
+	}
+
+	return updatedCampaign, nil
+}
+
+func (s *service) UnarchiveCampaign(input OwnedCampaignInput) (Campaign, error) {
+	campaign, err := s.findOwnedCampaign(input)
+	if err != nil {
+		return campaign, err
+	}
+
+	if campaign.ArchivedAt == nil {
+		return campaign, ErrCampaignNotArchived
+	}
+
+	campaign.ArchivedAt = nil
+
+	updatedCampaign, err := s.repository.Update(campaign)
+	if err != nil {
+		return campaign, err
+	}
+
+	return updatedCampaign, nil
+}
+
+func (s *service) findOwnedCampaign(input OwnedCampaignInput) (Campaign, error) {
+	campaign, err := s.repository.FindByID(input.ID)
+	if err != nil {
+		return campaign, err
+	}
+
+	if campaign.UserID != input.User.ID {
+		return campaign, ErrNotCampaignOwner
+	}
+
+	return campaign, nil
+}
+
+func (s *service) findOwnedImage(input GetCampaignImageInput) (CampaignImage, Campaign, error) {
+	campaignImage, err := s.repository.FindImageByID(input.ID)
+	if err != nil {
+		return campaignImage, Campaign{}, err
+	}
+
+	campaign, err := s.repository.FindByID(campaignImage.CampaignID)
+	if err != nil {
+		return campaignImage, campaign, err
+	}
+
+	if campaign.UserID != input.User.ID {
+		return campaignImage, campaign, ErrNotCampaignOwner
+	}
+
+	return campaignImage, campaign, nil
+}
+
+func (s *service) findOwnedFAQ(input GetCampaignFAQInput) (CampaignFAQ, Campaign, error) {
+	campaign, err := s.findOwnedCampaign(OwnedCampaignInput{ID: input.CampaignID, User: input.User})
+	if err != nil {
+		return CampaignFAQ{}, campaign, err
+	}
+
+	campaignFAQ, err := s.repository.FindFAQByID(input.ID)
+	if err != nil {
+		return campaignFAQ, campaign, err
+	}
+
+	if campaignFAQ.CampaignID != campaign.ID {
+		return CampaignFAQ{}, campaign, ErrCampaignFAQNotFound
+	}
+
+	return campaignFAQ, campaign, nil
+}
@@ -0,0 +1,186 @@
+package campaign
+
+import (
+	"backer/user"
+	"reflect"
+	"testing"
+)
+
+func TestDiffCampaigns(t *testing.T) {
+	before := Campaign{
+		ID:               1,
+		Name:             "Solar Kiosk",
+		ShortDescription: "Power for the market",
+		Description:      "A kiosk charging phones with solar power.",
+		GoalAmount:       1000000,
+		Perks:            "Sticker, T-shirt",
+		CurrentAmount:    250000,
+		BackerCount:      3,
+	}
+
+	tests := []struct {
+		name   string
+		modify func(campaign *Campaign)
+		want   []FieldChange
+	}{
+		{
+			name:   "nothing changed",
+			modify: func(campaign *Campaign) {},
+			want:   nil,
+		},
+		{
+			name: "untracked fields",
+			modify: func(campaign *Campaign) {
+				campaign.CurrentAmount = 500000
+				campaign.BackerCount = 4
+				campaign.Slug = "solar-kiosk"
+			},
+			want: nil,
+		},
+		{
+			name:   "name",
+			modify: func(campaign *Campaign) { campaign.Name = "Solar Kiosk 2" },
+			want:   []FieldChange{{Field: "name", OldValue: "Solar Kiosk", NewValue: "Solar Kiosk 2"}},
+		},
+		{
+			name:   "goal amount",
+			modify: func(campaign *Campaign) { campaign.GoalAmount = 2000000 },
+			want:   []FieldChange{{Field: "goal_amount", OldValue: 1000000, NewValue: 2000000}},
+		},
+		{
+			name: "several fields in a fixed order",
+			modify: func(campaign *Campaign) {
+				campaign.Perks = "Sticker"
+				campaign.ShortDescription = "Power for everyone"
+				campaign.Description = "A bigger kiosk."
+			},
+			want: []FieldChange{
+				{Field: "short_description", OldValue: "Power for the market", NewValue: "Power for everyone"},
+				{Field: "description", OldValue: "A kiosk charging phones with solar power.", NewValue: "A bigger kiosk."},
+				{Field: "perks", OldValue: "Sticker, T-shirt", NewValue: "Sticker"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			after := before
+			test.modify(&after)
+
+			if got := diffCampaigns(before, after); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("diffCampaigns() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+// fakeRepository records the revisions saveChanges writes. The other
+// methods of Repository are not used by these tests.
+type fakeRepository struct {
+	Repository
+	paidTransactions int64
+	revisions        []CampaignRevision
+}
+
+func (r *fakeRepository) CountPaidTransactions(campaignID int) (int64, error) {
+	return r.paidTransactions, nil
+}
+
+func (r *fakeRepository) UpdateWithRevision(campaign Campaign, revision CampaignRevision) (Campaign, error) {
+	r.revisions = append(r.revisions, revision)
+
+	return campaign, nil
+}
+
+func TestSaveChanges(t *testing.T) {
+	before := Campaign{ID: 1, Name: "Solar Kiosk", Description: "A kiosk.", GoalAmount: 1000000, Perks: "Sticker"}
+	editor := user.User{ID: 7}
+
+	tests := []struct {
+		name                string
+		paidTransactions    int64
+		materialChanges     []string
+		modify              func(campaign *Campaign)
+		wantRevision        bool
+		wantMaterial        bool
+		wantMaterialChanges []string
+	}{
+		{
+			name:         "no change",
+			modify:       func(campaign *Campaign) {},
+			wantRevision: false,
+		},
+		{
+			name:             "name change with backers",
+			paidTransactions: 1,
+			modify:           func(campaign *Campaign) { campaign.Name = "Solar Kiosk 2" },
+			wantRevision:     true,
+			wantMaterial:     false,
+		},
+		{
+			name:         "material change without backers",
+			modify:       func(campaign *Campaign) { campaign.Perks = "T-shirt" },
+			wantRevision: true,
+			wantMaterial: false,
+		},
+		{
+			name:                "material change with backers",
+			paidTransactions:    2,
+			modify:              func(campaign *Campaign) { campaign.Perks = "T-shirt"; campaign.Name = "Solar Kiosk 2" },
+			wantRevision:        true,
+			wantMaterial:        true,
+			wantMaterialChanges: []string{"perks"},
+		},
+		{
+			name:                "material changes add up",
+			paidTransactions:    2,
+			materialChanges:     []string{"perks"},
+			modify:              func(campaign *Campaign) { campaign.Perks = "Mug"; campaign.Description = "A bigger kiosk." },
+			wantRevision:        true,
+			wantMaterial:        true,
+			wantMaterialChanges: []string{"perks", "description"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repository := &fakeRepository{paidTransactions: test.paidTransactions}
+			s := NewService(repository)
+
+			before := before
+			before.MaterialChanges = test.materialChanges
+
+			after := before
+			test.modify(&after)
+
+			saved, err := s.saveChanges(before, after, editor)
+			if err != nil {
+				t.Fatalf("saveChanges() error = %v", err)
+			}
+
+			if !test.wantRevision {
+				if len(repository.revisions) != 0 || saved.LastEditedAt != nil {
+					t.Errorf("saveChanges() recorded %+v, want no revision", repository.revisions)
+				}
+				return
+			}
+
+			if len(repository.revisions) != 1 {
+				t.Fatalf("saveChanges() recorded %d revisions, want 1", len(repository.revisions))
+			}
+
+			revision := repository.revisions[0]
+			if revision.UserID != editor.ID || revision.CampaignID != before.ID || revision.IsMaterial != test.wantMaterial {
+				t.Errorf("revision = %+v, want one by user %d with IsMaterial %v", revision, editor.ID, test.wantMaterial)
+			}
+
+			if saved.LastEditedAt == nil {
+				t.Error("LastEditedAt = nil, want the time of the edit")
+			}
+
+			if !reflect.DeepEqual(saved.MaterialChanges, test.wantMaterialChanges) {
+				t.Errorf("MaterialChanges = %v, want %v", saved.MaterialChanges, test.wantMaterialChanges)
+			}
+		})
+	}
+}
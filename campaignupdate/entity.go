@@ -0,0 +1,36 @@
+package campaignupdate
+
+import "time"
+
+// Who can read an update
+const (
+	VisibilityPublic  = "public"
+	VisibilityBackers = "backers"
+)
+
+// CampaignUpdate is a news post the creator writes for a campaign's backers.
+// It stays a draft until PublishedAt is set.
+type CampaignUpdate struct {
+	ID                   int
+	CampaignID           int
+	UserID               int
+	Title                string
+	Body                 string
+	Visibility           string
+	PublishedAt          *time.Time
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	CampaignUpdateImages []CampaignUpdateImage
+
+	// IsLocked marks a backers-only update shown to someone who is not a
+	// backer, its body and images are left out
+	IsLocked bool `gorm:"-"`
+}
+
+type CampaignUpdateImage struct {
+	ID               int
+	CampaignUpdateID int
+	FileName         string
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
@@ -0,0 +1,10 @@
+package campaignupdate
+
+import "backer/apperror"
+
+var (
+	ErrCampaignUpdateNotFound      = apperror.NotFound("Campaign update not found")
+	ErrCampaignUpdateImageNotFound = apperror.NotFound("Campaign update image not found")
+	ErrBackersOnly                 = apperror.Forbidden("Only backers of the campaign can read this update")
+	ErrTooManyImages               = apperror.Validation("A campaign update can have at most 10 images")
+)
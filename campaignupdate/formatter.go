@@ -0,0 +1,75 @@
+package campaignupdate
+
+import (
+	"backer/storage"
+	"backer/upload"
+	"time"
+)
+
+type CampaignUpdateFormatter struct {
+	ID          int                            `json:"id"`
+	CampaignID  int                            `json:"campaign_id"`
+	Title       string                         `json:"title"`
+	Body        string                         `json:"body"`
+	Visibility  string                         `json:"visibility"`
+	IsPublished bool                           `json:"is_published"`
+	IsLocked    bool                           `json:"is_locked"`
+	PublishedAt *time.Time                     `json:"published_at"`
+	CreatedAt   time.Time                      `json:"created_at"`
+	UpdatedAt   time.Time                      `json:"updated_at"`
+	Images      []CampaignUpdateImageFormatter `json:"images"`
+}
+
+type CampaignUpdateImageFormatter struct {
+	ID         int               `json:"id"`
+	ImageURL   string            `json:"image_url"`
+	Renditions map[string]string `json:"renditions"`
+}
+
+func FormatCampaignUpdate(campaignUpdate CampaignUpdate, urls *storage.URLBuilder) CampaignUpdateFormatter {
+	formatter := CampaignUpdateFormatter{
+		ID:          campaignUpdate.ID,
+		CampaignID:  campaignUpdate.CampaignID,
+		Title:       campaignUpdate.Title,
+		Body:        campaignUpdate.Body,
+		Visibility:  campaignUpdate.Visibility,
+		IsPublished: campaignUpdate.PublishedAt != nil,
+		IsLocked:    campaignUpdate.IsLocked,
+		PublishedAt: campaignUpdate.PublishedAt,
+		CreatedAt:   campaignUpdate.CreatedAt,
+		UpdatedAt:   campaignUpdate.UpdatedAt,
+		Images:      FormatCampaignUpdateImages(campaignUpdate.CampaignUpdateImages, urls),
+	}
+
+	return formatter
+}
+
+func FormatCampaignUpdates(campaignUpdates []CampaignUpdate, urls *storage.URLBuilder) []CampaignUpdateFormatter {
+	formatters := []CampaignUpdateFormatter{}
+
+	for _, campaignUpdate := range campaignUpdates {
+		formatters = append(formatters, FormatCampaignUpdate(campaignUpdate, urls))
+	}
+
+	return formatters
+}
+
+func FormatCampaignUpdateImage(image CampaignUpdateImage, urls *storage.URLBuilder) CampaignUpdateImageFormatter {
+	formatter := CampaignUpdateImageFormatter{
+		ID:         image.ID,
+		ImageURL:   urls.URL(image.FileName),
+		Renditions: upload.RenditionURLs(image.FileName, urls.URL),
+	}
+
+	return formatter
+}
+
+func FormatCampaignUpdateImages(images []CampaignUpdateImage, urls *storage.URLBuilder) []CampaignUpdateImageFormatter {
+	formatters := []CampaignUpdateImageFormatter{}
+
+	for _, image := range images {
+		formatters = append(formatters, FormatCampaignUpdateImage(image, urls))
+	}
+
+	return formatters
+}
@@ -0,0 +1,33 @@
+package campaignupdate
+
+import "backer/user"
+
+// GetCampaignUpdatesInput lists the updates of a campaign, as seen by User.
+// User is empty for visitors who are not logged in.
+type GetCampaignUpdatesInput struct {
+	CampaignID int `uri:"id" binding:"required"`
+	User       user.User
+}
+
+type GetCampaignUpdateInput struct {
+	CampaignID int `uri:"id" binding:"required"`
+	ID         int `uri:"update_id" binding:"required"`
+	User       user.User
+}
+
+// CreateCampaignUpdateInput writes an update, which is published right away
+// when Publish is set and saved as a draft otherwise. The body is Markdown.
+type CreateCampaignUpdateInput struct {
+	Title      string `json:"title" binding:"required,max=255"`
+	Body       string `json:"body" binding:"required,max=65535"`
+	Visibility string `json:"visibility" binding:"required,oneof=public backers"`
+	Publish    bool   `json:"publish"`
+	User       user.User
+}
+
+type GetCampaignUpdateImageInput struct {
+	CampaignID int `uri:"id" binding:"required"`
+	UpdateID   int `uri:"update_id" binding:"required"`
+	ID         int `uri:"image_id" binding:"required"`
+	User       user.User
+}
@@ -0,0 +1,40 @@
+package campaignupdate
+
+import (
+	"backer/campaign"
+	"backer/mailer"
+	"backer/user"
+	"fmt"
+	"strings"
+)
+
+// Notifier tells backers about new campaign updates.
+type Notifier interface {
+	SendCampaignUpdate(backer user.User, campaign campaign.Campaign, campaignUpdate CampaignUpdate) error
+}
+
+type mailNotifier struct {
+	mailer mailer.Mailer
+	appURL string
+}
+
+// NewMailNotifier emails backers a copy of the update with a link into the
+// web app served at appURL.
+func NewMailNotifier(mailer mailer.Mailer, appURL string) *mailNotifier {
+	return &mailNotifier{mailer, strings.TrimSuffix(appURL, "/")}
+}
+
+func (n *mailNotifier) SendCampaignUpdate(backer user.User, campaign campaign.Campaign, campaignUpdate CampaignUpdate) error {
+	message, err := mailer.NewMessage(backer.Email, mailer.TemplateCampaignUpdate, map[string]string{
+		"Name":         backer.Name,
+		"CampaignName": campaign.Name,
+		"Title":        campaignUpdate.Title,
+		"Body":         campaignUpdate.Body,
+		"URL":          fmt.Sprintf("%s/campaigns/%d/updates/%d", n.appURL, campaign.ID, campaignUpdate.ID),
+	})
+	if err != nil {
+		return err
+	}
+
+	return n.mailer.Send(message)
+}
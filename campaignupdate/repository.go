@@ -0,0 +1,134 @@
+package campaignupdate
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	FindByCampaignID(campaignID int) ([]CampaignUpdate, error)
+	FindByID(ID int) (CampaignUpdate, error)
+	Save(campaignUpdate CampaignUpdate) (CampaignUpdate, error)
+	Update(campaignUpdate CampaignUpdate) (CampaignUpdate, error)
+	Publish(ID int, publishedAt time.Time) (bool, error)
+	Delete(campaignUpdate CampaignUpdate) error
+	FindImageByID(ID int) (CampaignUpdateImage, error)
+	SaveImage(image CampaignUpdateImage) (CampaignUpdateImage, error)
+	DeleteImage(image CampaignUpdateImage) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) FindByCampaignID(campaignID int) ([]CampaignUpdate, error) {
+	var campaignUpdates []CampaignUpdate
+
+	// Drafts, which have no publish time, come first
+	if err := r.db.
+		Where("campaign_id = ?", campaignID).
+		Preload("CampaignUpdateImages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("campaign_update_images.id")
+		}).
+		Order("published_at IS NOT NULL, published_at DESC, id DESC").
+		Find(&campaignUpdates).Error; err != nil {
+		return nil, err
+	}
+
+	return campaignUpdates, nil
+}
+
+func (r *repository) FindByID(ID int) (CampaignUpdate, error) {
+	var campaignUpdate CampaignUpdate
+
+	err := r.db.
+		Where("id = ?", ID).
+		Preload("CampaignUpdateImages", func(db *gorm.DB) *gorm.DB {
+			return db.Order("campaign_update_images.id")
+		}).
+		First(&campaignUpdate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return campaignUpdate, ErrCampaignUpdateNotFound
+	}
+	if err != nil {
+		return campaignUpdate, err
+	}
+
+	return campaignUpdate, nil
+}
+
+func (r *repository) Save(campaignUpdate CampaignUpdate) (CampaignUpdate, error) {
+	if err := r.db.Create(&campaignUpdate).Error; err != nil {
+		return campaignUpdate, err
+	}
+
+	return campaignUpdate, nil
+}
+
+// Update saves the update except for its publish time, which only Publish
+// sets.
+func (r *repository) Update(campaignUpdate CampaignUpdate) (CampaignUpdate, error) {
+	if err := r.db.Omit("PublishedAt").Save(&campaignUpdate).Error; err != nil {
+		return campaignUpdate, err
+	}
+
+	return campaignUpdate, nil
+}
+
+// Publish publishes the draft, reporting false when it was published
+// already.
+func (r *repository) Publish(ID int, publishedAt time.Time) (bool, error) {
+	result := r.db.
+		Model(&CampaignUpdate{}).
+		Where("id = ? AND published_at IS NULL", ID).
+		UpdateColumn("published_at", publishedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// Delete deletes the update with its images, whose files the caller removes
+// from the storage.
+func (r *repository) Delete(campaignUpdate CampaignUpdate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("campaign_update_id = ?", campaignUpdate.ID).Delete(&CampaignUpdateImage{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&campaignUpdate).Error
+	})
+}
+
+func (r *repository) FindImageByID(ID int) (CampaignUpdateImage, error) {
+	var image CampaignUpdateImage
+
+	err := r.db.Where("id = ?", ID).First(&image).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return image, ErrCampaignUpdateImageNotFound
+	}
+	if err != nil {
+		return image, err
+	}
+
+	return image, nil
+}
+
+func (r *repository) SaveImage(image CampaignUpdateImage) (CampaignUpdateImage, error) {
+	if err := r.db.Create(&image).Error; err != nil {
+		return image, err
+	}
+
+	return image, nil
+}
+
+func (r *repository) DeleteImage(image CampaignUpdateImage) error {
+	return r.db.Delete(&image).Error
+}
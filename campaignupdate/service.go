@@ -0,0 +1,369 @@
+package campaignupdate
+
+import (
+	"backer/campaign"
+	"backer/transaction"
+	"backer/user"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// maxImages is the number of images a single update can hold
+	maxImages = 10
+
+	// notificationQueueSize is the number of published updates waiting for
+	// their backers to be emailed
+	notificationQueueSize = 100
+)
+
+type Service interface {
+	GetCampaignUpdates(input GetCampaignUpdatesInput) ([]CampaignUpdate, error)
+	GetCampaignUpdate(input GetCampaignUpdateInput) (CampaignUpdate, error)
+	CreateCampaignUpdate(inputID GetCampaignUpdatesInput, inputData CreateCampaignUpdateInput) (CampaignUpdate, error)
+	UpdateCampaignUpdate(inputID GetCampaignUpdateInput, inputData CreateCampaignUpdateInput) (CampaignUpdate, error)
+	DeleteCampaignUpdate(input GetCampaignUpdateInput) (CampaignUpdate, error)
+	CreateCampaignUpdateImage(input GetCampaignUpdateInput, fileLocation string) (CampaignUpdateImage, error)
+	DeleteCampaignUpdateImage(input GetCampaignUpdateImageInput) (CampaignUpdateImage, error)
+}
+
+type service struct {
+	repository         Repository
+	campaignService    campaign.Service
+	transactionService transaction.Service
+	notifier           Notifier
+	notifications      chan notification
+	wait               sync.WaitGroup
+}
+
+// notification is a published update whose backers are still to be emailed.
+type notification struct {
+	campaign       campaign.Campaign
+	campaignUpdate CampaignUpdate
+}
+
+// NewService creates the campaign update service. Backers are notified of
+// published updates in the background, one update after the other, until
+// Close is called.
+func NewService(repository Repository, campaignService campaign.Service, transactionService transaction.Service, notifier Notifier) *service {
+	s := &service{
+		repository:         repository,
+		campaignService:    campaignService,
+		transactionService: transactionService,
+		notifier:           notifier,
+		notifications:      make(chan notification, notificationQueueSize),
+	}
+
+	s.wait.Add(1)
+	go s.work()
+
+	return s
+}
+
+// Close stops accepting notifications and waits until the queued ones are
+// sent.
+func (s *service) Close() {
+	close(s.notifications)
+	s.wait.Wait()
+}
+
+// GetCampaignUpdates lists the published updates of a campaign, and the
+// drafts too when the user owns it. Backers-only updates are locked for
+// everyone but the owner and the backers.
+func (s *service) GetCampaignUpdates(input GetCampaignUpdatesInput) ([]CampaignUpdate, error) {
+	campaign, err := s.campaignService.GetCampaignByID(campaign.GetCampaignInput{ID: input.CampaignID})
+	if err != nil {
+		return nil, err
+	}
+
+	campaignUpdates, err := s.repository.FindByCampaignID(campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	isOwner := input.User.ID != 0 && campaign.UserID == input.User.ID
+
+	canReadBackersOnly := isOwner
+	if !canReadBackersOnly {
+		canReadBackersOnly, err = s.transactionService.IsBacker(campaign.ID, input.User.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	visibleUpdates := []CampaignUpdate{}
+
+	for _, campaignUpdate := range campaignUpdates {
+		if campaignUpdate.PublishedAt == nil && !isOwner {
+			continue
+		}
+
+		if campaignUpdate.Visibility == VisibilityBackers && !canReadBackersOnly {
+			campaignUpdate.Body = ""
+			campaignUpdate.CampaignUpdateImages = nil
+			campaignUpdate.IsLocked = true
+		}
+
+		visibleUpdates = append(visibleUpdates, campaignUpdate)
+	}
+
+	return visibleUpdates, nil
+}
+
+func (s *service) GetCampaignUpdate(input GetCampaignUpdateInput) (CampaignUpdate, error) {
+	campaignUpdate, campaign, err := s.findCampaignUpdate(input.CampaignID, input.ID)
+	if err != nil {
+		return campaignUpdate, err
+	}
+
+	if campaign.UserID == input.User.ID && input.User.ID != 0 {
+		return campaignUpdate, nil
+	}
+
+	// Drafts do not exist for anyone but the owner
+	if campaignUpdate.PublishedAt == nil {
+		return CampaignUpdate{}, ErrCampaignUpdateNotFound
+	}
+
+	if campaignUpdate.Visibility == VisibilityBackers {
+		isBacker, err := s.transactionService.IsBacker(campaign.ID, input.User.ID)
+		if err != nil {
+			return CampaignUpdate{}, err
+		}
+
+		if !isBacker {
+			return CampaignUpdate{}, ErrBackersOnly
+		}
+	}
+
+	return campaignUpdate, nil
+}
+
+func (s *service) CreateCampaignUpdate(inputID GetCampaignUpdatesInput, inputData CreateCampaignUpdateInput) (CampaignUpdate, error) {
+	campaignDetail, err := s.findOwnedCampaign(inputID.CampaignID, inputData.User)
+	if err != nil {
+		return CampaignUpdate{}, err
+	}
+
+	if campaignDetail.ArchivedAt != nil {
+		return CampaignUpdate{}, campaign.ErrCampaignArchived
+	}
+
+	campaignUpdate := CampaignUpdate{
+		CampaignID: campaignDetail.ID,
+		UserID:     inputData.User.ID,
+		Title:      inputData.Title,
+		Body:       inputData.Body,
+		Visibility: inputData.Visibility,
+	}
+
+	if inputData.Publish {
+		now := time.Now()
+		campaignUpdate.PublishedAt = &now
+	}
+
+	newCampaignUpdate, err := s.repository.Save(campaignUpdate)
+	if err != nil {
+		return newCampaignUpdate, err
+	}
+
+	if newCampaignUpdate.PublishedAt != nil {
+		s.queueNotification(campaignDetail, newCampaignUpdate)
+	}
+
+	return newCampaignUpdate, nil
+}
+
+// UpdateCampaignUpdate edits an update. A draft is published when Publish
+// is set, while a published update stays published and its backers are not
+// notified again.
+func (s *service) UpdateCampaignUpdate(inputID GetCampaignUpdateInput, inputData CreateCampaignUpdateInput) (CampaignUpdate, error) {
+	campaignUpdate, campaignDetail, err := s.findOwnedCampaignUpdate(inputID.CampaignID, inputID.ID, inputData.User)
+	if err != nil {
+		return campaignUpdate, err
+	}
+
+	if campaignDetail.ArchivedAt != nil {
+		return campaignUpdate, campaign.ErrCampaignArchived
+	}
+
+	campaignUpdate.Title = inputData.Title
+	campaignUpdate.Body = inputData.Body
+	campaignUpdate.Visibility = inputData.Visibility
+
+	updatedCampaignUpdate, err := s.repository.Update(campaignUpdate)
+	if err != nil {
+		return updatedCampaignUpdate, err
+	}
+
+	if !inputData.Publish || updatedCampaignUpdate.PublishedAt != nil {
+		return updatedCampaignUpdate, nil
+	}
+
+	// Only the request that actually publishes the draft notifies, even
+	// when the owner publishes it twice at the same time
+	now := time.Now()
+
+	published, err := s.repository.Publish(updatedCampaignUpdate.ID, now)
+	if err != nil {
+		return updatedCampaignUpdate, err
+	}
+
+	if !published {
+		return s.repository.FindByID(updatedCampaignUpdate.ID)
+	}
+
+	updatedCampaignUpdate.PublishedAt = &now
+	s.queueNotification(campaignDetail, updatedCampaignUpdate)
+
+	return updatedCampaignUpdate, nil
+}
+
+func (s *service) DeleteCampaignUpdate(input GetCampaignUpdateInput) (CampaignUpdate, error) {
+	campaignUpdate, campaignDetail, err := s.findOwnedCampaignUpdate(input.CampaignID, input.ID, input.User)
+	if err != nil {
+		return campaignUpdate, err
+	}
+
+	if campaignDetail.ArchivedAt != nil {
+		return campaignUpdate, campaign.ErrCampaignArchived
+	}
+
+	if err := s.repository.Delete(campaignUpdate); err != nil {
+		return campaignUpdate, err
+	}
+
+	return campaignUpdate, nil
+}
+
+func (s *service) CreateCampaignUpdateImage(input GetCampaignUpdateInput, fileLocation string) (CampaignUpdateImage, error) {
+	campaignUpdate, campaignDetail, err := s.findOwnedCampaignUpdate(input.CampaignID, input.ID, input.User)
+	if err != nil {
+		return CampaignUpdateImage{}, err
+	}
+
+	if campaignDetail.ArchivedAt != nil {
+		return CampaignUpdateImage{}, campaign.ErrCampaignArchived
+	}
+
+	if len(campaignUpdate.CampaignUpdateImages) >= maxImages {
+		return CampaignUpdateImage{}, ErrTooManyImages
+	}
+
+	image := CampaignUpdateImage{
+		CampaignUpdateID: campaignUpdate.ID,
+		FileName:         fileLocation,
+	}
+
+	newImage, err := s.repository.SaveImage(image)
+	if err != nil {
+		return newImage, err
+	}
+
+	return newImage, nil
+}
+
+func (s *service) DeleteCampaignUpdateImage(input GetCampaignUpdateImageInput) (CampaignUpdateImage, error) {
+	campaignUpdate, campaignDetail, err := s.findOwnedCampaignUpdate(input.CampaignID, input.UpdateID, input.User)
+	if err != nil {
+		return CampaignUpdateImage{}, err
+	}
+
+	if campaignDetail.ArchivedAt != nil {
+		return CampaignUpdateImage{}, campaign.ErrCampaignArchived
+	}
+
+	image, err := s.repository.FindImageByID(input.ID)
+	if err != nil {
+		return image, err
+	}
+
+	if image.CampaignUpdateID != campaignUpdate.ID {
+		return CampaignUpdateImage{}, ErrCampaignUpdateImageNotFound
+	}
+
+	if err := s.repository.DeleteImage(image); err != nil {
+		return image, err
+	}
+
+	return image, nil
+}
+
+// queueNotification hands the update over to the background worker. The
+// update is published either way, so a full queue is only logged.
+func (s *service) queueNotification(campaign campaign.Campaign, campaignUpdate CampaignUpdate) {
+	select {
+	case s.notifications <- notification{campaign, campaignUpdate}:
+	default:
+		log.Printf("notification queue is full, backers of campaign %d are not notified of update %d", campaign.ID, campaignUpdate.ID)
+	}
+}
+
+func (s *service) work() {
+	defer s.wait.Done()
+
+	for queued := range s.notifications {
+		s.notifyBackers(queued.campaign, queued.campaignUpdate)
+	}
+}
+
+// notifyBackers emails the update to every backer of the campaign. It runs
+// in the background, so failures are only logged.
+func (s *service) notifyBackers(campaign campaign.Campaign, campaignUpdate CampaignUpdate) {
+	backers, err := s.transactionService.GetBackers(campaign.ID)
+	if err != nil {
+		log.Printf("failed to get backers of campaign %d: %v", campaign.ID, err)
+		return
+	}
+
+	for _, backer := range backers {
+		if err := s.notifier.SendCampaignUpdate(backer, campaign, campaignUpdate); err != nil {
+			log.Printf("failed to notify backer %d of campaign update %d: %v", backer.ID, campaignUpdate.ID, err)
+		}
+	}
+}
+
+func (s *service) findCampaignUpdate(campaignID int, ID int) (CampaignUpdate, campaign.Campaign, error) {
+	campaign, err := s.campaignService.GetCampaignByID(campaign.GetCampaignInput{ID: campaignID})
+	if err != nil {
+		return CampaignUpdate{}, campaign, err
+	}
+
+	campaignUpdate, err := s.repository.FindByID(ID)
+	if err != nil {
+		return campaignUpdate, campaign, err
+	}
+
+	if campaignUpdate.CampaignID != campaign.ID {
+		return CampaignUpdate{}, campaign, ErrCampaignUpdateNotFound
+	}
+
+	return campaignUpdate, campaign, nil
+}
+
+func (s *service) findOwnedCampaign(campaignID int, user user.User) (campaign.Campaign, error) {
+	campaignDetail, err := s.campaignService.GetCampaignByID(campaign.GetCampaignInput{ID: campaignID})
+	if err != nil {
+		return campaignDetail, err
+	}
+
+	if campaignDetail.UserID != user.ID {
+		return campaignDetail, campaign.ErrNotCampaignOwner
+	}
+
+	return campaignDetail, nil
+}
+
+func (s *service) findOwnedCampaignUpdate(campaignID int, ID int, user user.User) (CampaignUpdate, campaign.Campaign, error) {
+	campaignUpdate, campaignDetail, err := s.findCampaignUpdate(campaignID, ID)
+	if err != nil {
+		return campaignUpdate, campaignDetail, err
+	}
+
+	if campaignDetail.UserID != user.ID {
+		return campaignUpdate, campaignDetail, campaign.ErrNotCampaignOwner
+	}
+
+	return campaignUpdate, campaignDetail, nil
+}
@@ -0,0 +1,29 @@
+package comment
+
+import (
+	"backer/user"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Comment is posted on a campaign, either at the top level or as a reply
+// to a top-level comment. Replies cannot be replied to.
+type Comment struct {
+	ID         int
+	CampaignID int
+	UserID     int
+	ParentID   *int
+	Body       string
+	EditedAt   *time.Time
+	PinnedAt   *time.Time
+	HiddenAt   *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	DeletedAt  gorm.DeletedAt
+	User       user.User
+	Replies    []Comment `gorm:"foreignKey:ParentID"`
+
+	// IsCreator marks comments by the owner of the campaign
+	IsCreator bool `gorm:"-"`
+}
@@ -0,0 +1,11 @@
+package comment
+
+import "backer/apperror"
+
+var (
+	ErrCommentNotFound  = apperror.NotFound("Comment not found")
+	ErrNotCommentAuthor = apperror.Forbidden("Not the author of the comment")
+	ErrNotModerator     = apperror.Forbidden("Only the campaign owner or an administrator can moderate comments")
+	ErrNestedReply      = apperror.Validation("Replies cannot be replied to")
+	ErrPinnedReply      = apperror.Validation("Only top-level comments can be pinned")
+)
@@ -0,0 +1,60 @@
+package comment
+
+import (
+	"backer/storage"
+	"time"
+)
+
+type CommentFormatter struct {
+	ID         int                  `json:"id"`
+	CampaignID int                  `json:"campaign_id"`
+	ParentID   *int                 `json:"parent_id"`
+	Body       string               `json:"body"`
+	IsCreator  bool                 `json:"is_creator"`
+	IsPinned   bool                 `json:"is_pinned"`
+	IsHidden   bool                 `json:"is_hidden"`
+	IsEdited   bool                 `json:"is_edited"`
+	CreatedAt  time.Time            `json:"created_at"`
+	EditedAt   *time.Time           `json:"edited_at"`
+	User       CommentUserFormatter `json:"user"`
+	Replies    []CommentFormatter   `json:"replies"`
+}
+
+type CommentUserFormatter struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	ImageURL string `json:"image_url"`
+}
+
+func FormatComment(comment Comment, urls *storage.URLBuilder) CommentFormatter {
+	formatter := CommentFormatter{
+		ID:         comment.ID,
+		CampaignID: comment.CampaignID,
+		ParentID:   comment.ParentID,
+		Body:       comment.Body,
+		IsCreator:  comment.IsCreator,
+		IsPinned:   comment.PinnedAt != nil,
+		IsHidden:   comment.HiddenAt != nil,
+		IsEdited:   comment.EditedAt != nil,
+		CreatedAt:  comment.CreatedAt,
+		EditedAt:   comment.EditedAt,
+		User: CommentUserFormatter{
+			ID:       comment.User.ID,
+			Name:     comment.User.Name,
+			ImageURL: urls.AvatarURL(comment.User.AvatarFileName),
+		},
+		Replies: FormatComments(comment.Replies, urls),
+	}
+
+	return formatter
+}
+
+func FormatComments(comments []Comment, urls *storage.URLBuilder) []CommentFormatter {
+	formatters := []CommentFormatter{}
+
+	for _, comment := range comments {
+		formatters = append(formatters, FormatComment(comment, urls))
+	}
+
+	return formatters
+}
@@ -0,0 +1,35 @@
+package comment
+
+import "backer/user"
+
+// GetCommentsInput lists the comments of a campaign, as seen by User. User
+// is empty for visitors who are not logged in.
+type GetCommentsInput struct {
+	CampaignID int `uri:"id" binding:"required"`
+	User       user.User
+}
+
+// PageInput selects a page of top-level comments, each with all its replies.
+type PageInput struct {
+	Page  int `form:"page" binding:"omitempty,min=1"`
+	Limit int `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type GetCommentInput struct {
+	CampaignID int `uri:"id" binding:"required"`
+	ID         int `uri:"comment_id" binding:"required"`
+	User       user.User
+}
+
+// CreateCommentInput posts a comment, replying to the top-level comment
+// ParentID when set.
+type CreateCommentInput struct {
+	Body     string `json:"body" binding:"required,max=5000"`
+	ParentID int    `json:"parent_id"`
+	User     user.User
+}
+
+type UpdateCommentInput struct {
+	Body string `json:"body" binding:"required,max=5000"`
+	User user.User
+}
@@ -0,0 +1,96 @@
+package comment
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	FindByCampaignID(campaignID int, includeHidden bool, offset int, limit int) ([]Comment, error)
+	FindByID(ID int) (Comment, error)
+	Save(comment Comment) (Comment, error)
+	Update(comment Comment) (Comment, error)
+	Delete(comment Comment) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+// FindByCampaignID returns a page of the top-level comments of a campaign,
+// pinned ones first and then the newest, with their replies oldest first.
+// Hidden comments and replies are left out unless includeHidden is set.
+func (r *repository) FindByCampaignID(campaignID int, includeHidden bool, offset int, limit int) ([]Comment, error) {
+	var comments []Comment
+
+	visible := func(db *gorm.DB) *gorm.DB {
+		if includeHidden {
+			return db
+		}
+
+		return db.Where("comments.hidden_at IS NULL")
+	}
+
+	if err := r.db.
+		Scopes(visible).
+		Where("campaign_id = ? AND parent_id IS NULL", campaignID).
+		Preload("User").
+		Preload("Replies", func(db *gorm.DB) *gorm.DB {
+			return db.Scopes(visible).Order("comments.id")
+		}).
+		Preload("Replies.User").
+		Order("pinned_at IS NULL, pinned_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (r *repository) FindByID(ID int) (Comment, error) {
+	var comment Comment
+
+	err := r.db.Where("id = ?", ID).Preload("User").First(&comment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return comment, ErrCommentNotFound
+	}
+	if err != nil {
+		return comment, err
+	}
+
+	return comment, nil
+}
+
+func (r *repository) Save(comment Comment) (Comment, error) {
+	if err := r.db.Create(&comment).Error; err != nil {
+		return comment, err
+	}
+
+	return comment, nil
+}
+
+func (r *repository) Update(comment Comment) (Comment, error) {
+	if err := r.db.Omit("User", "Replies").Save(&comment).Error; err != nil {
+		return comment, err
+	}
+
+	return comment, nil
+}
+
+// Delete soft deletes the comment together with its replies.
+func (r *repository) Delete(comment Comment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("parent_id = ?", comment.ID).Delete(&Comment{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&comment).Error
+	})
+}
@@ -0,0 +1,278 @@
+package comment
+
+import (
+	"backer/campaign"
+	"backer/user"
+	"time"
+)
+
+type Service interface {
+	GetComments(input GetCommentsInput, page PageInput) ([]Comment, error)
+	CreateComment(inputID GetCommentsInput, inputData CreateCommentInput) (Comment, error)
+	UpdateComment(inputID GetCommentInput, inputData UpdateCommentInput) (Comment, error)
+	DeleteComment(input GetCommentInput) (Comment, error)
+	HideComment(input GetCommentInput) (Comment, error)
+	UnhideComment(input GetCommentInput) (Comment, error)
+	PinComment(input GetCommentInput) (Comment, error)
+	UnpinComment(input GetCommentInput) (Comment, error)
+}
+
+// defaultPageLimit is the number of top-level comments on a page when the
+// request does not ask for another one.
+const defaultPageLimit = 20
+
+type service struct {
+	repository            Repository
+	campaignService       campaign.Service
+	requireAdminTwoFactor bool
+}
+
+// NewService creates the comment service. requireAdminTwoFactor only lets
+// administrators with two-factor authentication enabled moderate comments
+// of other users' campaigns, like on the admin endpoints.
+func NewService(repository Repository, campaignService campaign.Service, requireAdminTwoFactor bool) *service {
+	return &service{repository, campaignService, requireAdminTwoFactor}
+}
+
+// GetComments lists a page of the comments of a campaign. Hidden comments
+// are only listed for the moderators of the campaign.
+func (s *service) GetComments(input GetCommentsInput, page PageInput) ([]Comment, error) {
+	campaignDetail, err := s.campaignService.GetCampaignByID(campaign.GetCampaignInput{ID: input.CampaignID})
+	if err != nil {
+		return nil, err
+	}
+
+	if page.Page == 0 {
+		page.Page = 1
+	}
+
+	if page.Limit == 0 {
+		page.Limit = defaultPageLimit
+	}
+
+	canSeeHidden := s.checkModerator(campaignDetail, input.User) == nil
+
+	comments, err := s.repository.FindByCampaignID(campaignDetail.ID, canSeeHidden, (page.Page-1)*page.Limit, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range comments {
+		comments[i].IsCreator = comments[i].UserID == campaignDetail.UserID
+
+		for j := range comments[i].Replies {
+			comments[i].Replies[j].IsCreator = comments[i].Replies[j].UserID == campaignDetail.UserID
+		}
+	}
+
+	return comments, nil
+}
+
+func (s *service) CreateComment(inputID GetCommentsInput, inputData CreateCommentInput) (Comment, error) {
+	campaignDetail, err := s.campaignService.GetCampaignByID(campaign.GetCampaignInput{ID: inputID.CampaignID})
+	if err != nil {
+		return Comment{}, err
+	}
+
+	if campaignDetail.ArchivedAt != nil {
+		return Comment{}, campaign.ErrCampaignArchived
+	}
+
+	comment := Comment{
+		CampaignID: campaignDetail.ID,
+		UserID:     inputData.User.ID,
+		Body:       inputData.Body,
+	}
+
+	if inputData.ParentID != 0 {
+		parent, err := s.repository.FindByID(inputData.ParentID)
+		if err != nil {
+			return Comment{}, err
+		}
+
+		if parent.CampaignID != campaignDetail.ID {
+			return Comment{}, ErrCommentNotFound
+		}
+
+		if parent.ParentID != nil {
+			return Comment{}, ErrNestedReply
+		}
+
+		comment.ParentID = &parent.ID
+	}
+
+	newComment, err := s.repository.Save(comment)
+	if err != nil {
+		return newComment, err
+	}
+
+	newComment.User = inputData.User
+	newComment.IsCreator = newComment.UserID == campaignDetail.UserID
+
+	return newComment, nil
+}
+
+func (s *service) UpdateComment(inputID GetCommentInput, inputData UpdateCommentInput) (Comment, error) {
+	comment, _, err := s.findComment(inputID.CampaignID, inputID.ID)
+	if err != nil {
+		return comment, err
+	}
+
+	if comment.UserID != inputData.User.ID {
+		return comment, ErrNotCommentAuthor
+	}
+
+	if comment.Body == inputData.Body {
+		return comment, nil
+	}
+
+	now := time.Now()
+	comment.Body = inputData.Body
+	comment.EditedAt = &now
+
+	updatedComment, err := s.repository.Update(comment)
+	if err != nil {
+		return updatedComment, err
+	}
+
+	return updatedComment, nil
+}
+
+// DeleteComment deletes a comment of the user, along with its replies.
+func (s *service) DeleteComment(input GetCommentInput) (Comment, error) {
+	comment, _, err := s.findComment(input.CampaignID, input.ID)
+	if err != nil {
+		return comment, err
+	}
+
+	if comment.UserID != input.User.ID {
+		return comment, ErrNotCommentAuthor
+	}
+
+	if err := s.repository.Delete(comment); err != nil {
+		return comment, err
+	}
+
+	return comment, nil
+}
+
+func (s *service) HideComment(input GetCommentInput) (Comment, error) {
+	comment, err := s.findModeratedComment(input)
+	if err != nil {
+		return comment, err
+	}
+
+	if comment.HiddenAt != nil {
+		return comment, nil
+	}
+
+	now := time.Now()
+	comment.HiddenAt = &now
+
+	return s.repository.Update(comment)
+}
+
+func (s *service) UnhideComment(input GetCommentInput) (Comment, error) {
+	comment, err := s.findModeratedComment(input)
+	if err != nil {
+		return comment, err
+	}
+
+	if comment.HiddenAt == nil {
+		return comment, nil
+	}
+
+	comment.HiddenAt = nil
+
+	return s.repository.Update(comment)
+}
+
+func (s *service) PinComment(input GetCommentInput) (Comment, error) {
+	comment, err := s.findModeratedComment(input)
+	if err != nil {
+		return comment, err
+	}
+
+	if comment.ParentID != nil {
+		return comment, ErrPinnedReply
+	}
+
+	if comment.PinnedAt != nil {
+		return comment, nil
+	}
+
+	now := time.Now()
+	comment.PinnedAt = &now
+
+	return s.repository.Update(comment)
+}
+
+func (s *service) UnpinComment(input GetCommentInput) (Comment, error) {
+	comment, err := s.findModeratedComment(input)
+	if err != nil {
+		return comment, err
+	}
+
+	if comment.PinnedAt == nil {
+		return comment, nil
+	}
+
+	comment.PinnedAt = nil
+
+	return s.repository.Update(comment)
+}
+
+func (s *service) findComment(campaignID int, ID int) (Comment, campaign.Campaign, error) {
+	campaignDetail, err := s.campaignService.GetCampaignByID(campaign.GetCampaignInput{ID: campaignID})
+	if err != nil {
+		return Comment{}, campaignDetail, err
+	}
+
+	comment, err := s.repository.FindByID(ID)
+	if err != nil {
+		return comment, campaignDetail, err
+	}
+
+	if comment.CampaignID != campaignDetail.ID {
+		return Comment{}, campaignDetail, ErrCommentNotFound
+	}
+
+	comment.IsCreator = comment.UserID == campaignDetail.UserID
+
+	return comment, campaignDetail, nil
+}
+
+func (s *service) findModeratedComment(input GetCommentInput) (Comment, error) {
+	comment, campaignDetail, err := s.findComment(input.CampaignID, input.ID)
+	if err != nil {
+		return comment, err
+	}
+
+	if err := s.checkModerator(campaignDetail, input.User); err != nil {
+		return comment, err
+	}
+
+	return comment, nil
+}
+
+// checkModerator reports whether the user can hide and pin comments on the
+// campaign, which its owner and administrators can.
+func (s *service) checkModerator(campaign campaign.Campaign, currentUser user.User) error {
+	if currentUser.ID == 0 {
+		return ErrNotModerator
+	}
+
+	if campaign.UserID == currentUser.ID {
+		return nil
+	}
+
+	if currentUser.Role != "admin" {
+		return ErrNotModerator
+	}
+
+	if s.requireAdminTwoFactor && currentUser.TwoFactorEnabledAt == nil {
+		return user.ErrTwoFactorRequired
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Config struct {
+	DatabaseDSN string
+	Storage     Storage
+
+	// PublicBaseURL is the scheme and host clients reach the API on
+	PublicBaseURL string
+	// TrustedProxies are the addresses or CIDR ranges of the reverse proxies
+	// in front of the API. Only their X-Forwarded-For is believed, without
+	// any the client IP is the address of the connection.
+	TrustedProxies []string
+	// CDNBaseURL, when set, is the prefix of every uploaded file's URL
+	CDNBaseURL string
+
+	AvatarPlaceholder        string
+	CampaignImagePlaceholder string
+
+	// AppURL is where the web app is served, links in emails point there
+	AppURL string
+	Mail   Mail
+
+	// PasswordHasher is either "bcrypt" or "argon2id"
+	PasswordHasher string
+	BcryptCost     int
+
+	// RequireEmailVerification keeps unverified users from creating
+	// campaigns and posting comments
+	RequireEmailVerification bool
+	// RequireAdminTwoFactor locks administrators out of admin endpoints
+	// until they enable two-factor authentication
+	RequireAdminTwoFactor bool
+
+	OAuth OAuth
+}
+
+// OAuth holds the client registrations of the social login providers. A
+// provider without a client ID is disabled.
+type OAuth struct {
+	// GoogleIssuer can point at any OpenID Connect issuer, e.g. a mock one
+	// during development
+	GoogleIssuer       string
+	GoogleClientID     string
+	GoogleClientSecret string
+
+	GitHubClientID     string
+	GitHubClientSecret string
+}
+
+type Mail struct {
+	// Driver is "smtp", or "file" to write emails into CaptureDir
+	Driver     string
+	From       string
+	CaptureDir string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+}
+
+type Storage struct {
+	// Driver is either "local" or "s3"
+	Driver string
+
+	LocalDir     string
+	LocalBaseURL string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PublicURL string
+}
+
+// Load reads the configuration from environment variables, falling back to
+// defaults suitable for local development.
+func Load() Config {
+	config := Config{
+		// refer https://github.com/go-sal-driver/mysql#dsn-data-source-name for details
+		DatabaseDSN: env("DATABASE_DSN", "root:@tcp(127.0.0.1:3306)/backer?charset=utf8mb4&parseTime=true&loc=Local"),
+		Storage: Storage{
+			Driver:       env("STORAGE_DRIVER", "local"),
+			LocalDir:     env("STORAGE_LOCAL_DIR", "."),
+			LocalBaseURL: env("STORAGE_LOCAL_BASE_URL", ""),
+			S3Endpoint:   env("STORAGE_S3_ENDPOINT", "http://localhost:9000"),
+			S3Region:     env("STORAGE_S3_REGION", "us-east-1"),
+			S3Bucket:     env("STORAGE_S3_BUCKET", "backer"),
+			S3AccessKey:  env("STORAGE_S3_ACCESS_KEY", ""),
+			S3SecretKey:  env("STORAGE_S3_SECRET_KEY", ""),
+			S3PublicURL:  env("STORAGE_S3_PUBLIC_URL", ""),
+		},
+		PublicBaseURL:            env("PUBLIC_BASE_URL", "http://localhost:8080"),
+		TrustedProxies:           envList("TRUSTED_PROXIES"),
+		CDNBaseURL:               env("CDN_BASE_URL", ""),
+		AvatarPlaceholder:        env("AVATAR_PLACEHOLDER_URL", "/static/placeholders/avatar.png"),
+		CampaignImagePlaceholder: env("CAMPAIGN_IMAGE_PLACEHOLDER_URL", "/static/placeholders/campaign-image.png"),
+		AppURL:                   env("APP_URL", "http://localhost:3000"),
+		Mail: Mail{
+			Driver:       env("MAIL_DRIVER", "file"),
+			From:         env("MAIL_FROM", "Backer <no-reply@backer.local>"),
+			CaptureDir:   env("MAIL_CAPTURE_DIR", "mails"),
+			SMTPHost:     env("SMTP_HOST", "localhost"),
+			SMTPPort:     envInt("SMTP_PORT", 587),
+			SMTPUsername: env("SMTP_USERNAME", ""),
+			SMTPPassword: env("SMTP_PASSWORD", ""),
+		},
+		PasswordHasher:           env("PASSWORD_HASHER", "bcrypt"),
+		BcryptCost:               envInt("BCRYPT_COST", 12),
+		RequireEmailVerification: envBool("REQUIRE_EMAIL_VERIFICATION", false),
+		RequireAdminTwoFactor:    envBool("REQUIRE_ADMIN_TWO_FACTOR", false),
+		OAuth: OAuth{
+			GoogleIssuer:       env("OAUTH_GOOGLE_ISSUER", "https://accounts.google.com"),
+			GoogleClientID:     env("OAUTH_GOOGLE_CLIENT_ID", ""),
+			GoogleClientSecret: env("OAUTH_GOOGLE_CLIENT_SECRET", ""),
+			GitHubClientID:     env("OAUTH_GITHUB_CLIENT_ID", ""),
+			GitHubClientSecret: env("OAUTH_GITHUB_CLIENT_SECRET", ""),
+		},
+	}
+
+	return config
+}
+
+func env(key string, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+
+	return fallback
+}
+
+// envList splits a comma separated variable, which is empty when unset.
+func envList(key string) []string {
+	var values []string
+
+	for _, value := range strings.Split(env(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func envBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(env(key, strconv.FormatBool(fallback)))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(env(key, strconv.Itoa(fallback)))
+	if err != nil {
+		return fallback
+	}
+
+	return value
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"archive/zip"
+	"backer/apikey"
+	"backer/apperror"
+	"backer/campaign"
+	"backer/helper"
+	"backer/session"
+	"backer/storage"
+	"backer/transaction"
+	"backer/user"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type accountHandler struct {
+	userService        user.Service
+	sessionService     session.Service
+	apiKeyService      apikey.Service
+	campaignService    campaign.Service
+	transactionService transaction.Service
+	store              storage.Store
+	urls               *storage.URLBuilder
+}
+
+func NewAccountHandler(
+	userService user.Service,
+	sessionService session.Service,
+	apiKeyService apikey.Service,
+	campaignService campaign.Service,
+	transactionService transaction.Service,
+	store storage.Store,
+	urls *storage.URLBuilder,
+) *accountHandler {
+	return &accountHandler{userService, sessionService, apiKeyService, campaignService, transactionService, store, urls}
+}
+
+func (h *accountHandler) DeleteAccount(ctx *gin.Context) {
+	/**
+	 * 1. Confirm with the password, a two-factor code or a fresh login
+	 * 2. Anonymise the user and sign out everywhere in one go, transactions
+	 *    keep pointing at the scrubbed row
+	 * 3. Remove the avatar files
+	 */
+
+	var input user.DeleteAccountInput
+
+	// A fresh login needs no body at all
+	if err := ctx.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		abortWithError(ctx, "Failed to delete account", apperror.InvalidInput(err))
+		return
+	}
+
+	currentUser := ctx.MustGet("currentUser").(user.User)
+
+	if currentSession, ok := ctx.Get("currentSession"); ok {
+		input.LoggedInAt = currentSession.(session.Session).CreatedAt
+	}
+
+	if _, err := h.userService.DeleteAccount(currentUser.ID, input); err != nil {
+		abortWithError(ctx, "Failed to delete account", err)
+		return
+	}
+
+	if currentUser.AvatarFileName != "" {
+		removeImage(h.store, currentUser.AvatarFileName)
+	}
+
+	response := helper.APIResponse("Account successfully deleted", http.StatusOK, "success", nil)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *accountHandler) ExportAccount(ctx *gin.Context) {
+	/**
+	 * 1. Gather everything stored about the user
+	 * 2. Write each kind of data as a JSON file into a ZIP archive, together
+	 *    with the uploaded images
+	 * 3. Stream the archive as a download
+	 */
+
+	currentUser := ctx.MustGet("currentUser").(user.User)
+
+	identities, err := h.userService.GetIdentities(currentUser.ID)
+	if err != nil {
+		abortWithError(ctx, "Failed to export account", err)
+		return
+	}
+
+	campaigns, err := h.campaignService.GetCampaigns(currentUser.ID)
+	if err != nil {
+		abortWithError(ctx, "Failed to export account", err)
+		return
+	}
+
+	// The campaign list only loads the primary images
+	campaignDetails := []campaign.CampaignDetailFormatter{}
+	imageFileNames := []string{}
+
+	for _, userCampaign := range campaigns {
+		campaignDetail, err := h.campaignService.GetCampaignByID(campaign.GetCampaignInput{ID: userCampaign.ID})
+		if err != nil {
+			abortWithError(ctx, "Failed to export account", err)
+			return
+		}
+
+		campaignDetails = append(campaignDetails, campaign.FormatCampaignDetail(campaignDetail, h.urls))
+
+		for _, image := range campaignDetail.CampaignImages {
+			imageFileNames = append(imageFileNames, image.FileName)
+		}
+	}
+
+	if currentUser.AvatarFileName != "" {
+		imageFileNames = append(imageFileNames, currentUser.AvatarFileName)
+	}
+
+	transactions, err := h.transactionService.GetTransactionsByUserID(currentUser.ID)
+	if err != nil {
+		abortWithError(ctx, "Failed to export account", err)
+		return
+	}
+
+	sessions, err := h.sessionService.GetSessions(currentUser.ID)
+	if err != nil {
+		abortWithError(ctx, "Failed to export account", err)
+		return
+	}
+
+	apiKeys, err := h.apiKeyService.GetAPIKeys(currentUser.ID)
+	if err != nil {
+		abortWithError(ctx, "Failed to export account", err)
+		return
+	}
+
+	var currentSessionID int
+	if currentSession, ok := ctx.Get("currentSession"); ok {
+		currentSessionID = currentSession.(session.Session).ID
+	}
+
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user.FormatUserExport(currentUser, identities)},
+		{"campaigns.json", campaignDetails},
+		{"transactions.json", transaction.FormatTransactions(transactions)},
+		{"sessions.json", session.FormatSessions(sessions, currentSessionID)},
+		{"api_keys.json", apikey.FormatAPIKeys(apiKeys)},
+	}
+
+	fileName := fmt.Sprintf("backer-export-%d-%s.zip", currentUser.ID, time.Now().Format("20060102"))
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	ctx.Header("Content-Type", "application/zip")
+	ctx.Status(http.StatusOK)
+
+	// The images can be large, so the archive goes out as it is written.
+	// Once the first bytes are sent the status cannot change anymore, a
+	// failure can only cut the download short, which leaves a broken ZIP.
+	zipWriter := zip.NewWriter(ctx.Writer)
+
+	for _, file := range files {
+		if err := writeJSONFile(zipWriter, file.name, file.data); err != nil {
+			log.Printf("failed to export account of user %d: %v", currentUser.ID, err)
+			return
+		}
+	}
+
+	for _, fileName := range imageFileNames {
+		if err := h.writeStoredFile(zipWriter, fileName); err != nil {
+			log.Printf("failed to export account of user %d: %v", currentUser.ID, err)
+			return
+		}
+	}
+
+	if err := zipWriter.Close(); err != nil {
+		log.Printf("failed to export account of user %d: %v", currentUser.ID, err)
+	}
+}
+
+// writeStoredFile copies an uploaded file into the archive under "files/",
+// skipping files that are already gone from the store.
+func (h *accountHandler) writeStoredFile(zipWriter *zip.Writer, key string) error {
+	file, err := h.store.Get(key)
+	if errors.Is(err, storage.ErrFileNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer, err := zipWriter.Create("files/" + key)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(writer, file)
+
+	return err
+}
+
+func writeJSONFile(zipWriter *zip.Writer, name string, data interface{}) error {
+	writer, err := zipWriter.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(data)
+}
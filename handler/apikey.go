@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"backer/apikey"
+	"backer/apperror"
+	"backer/helper"
+	"backer/user"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type apiKeyHandler struct {
+	service apikey.Service
+}
+
+func NewAPIKeyHandler(service apikey.Service) *apiKeyHandler {
+	return &apiKeyHandler{service}
+}
+
+func (h *apiKeyHandler) GetAPIKeys(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(user.User)
+
+	apiKeys, err := h.service.GetAPIKeys(currentUser.ID)
+	if err != nil {
+		abortWithError(ctx, "Failed to get API keys", err)
+		return
+	}
+
+	response := helper.APIResponse("List of API keys", http.StatusOK, "success", apikey.FormatAPIKeys(apiKeys))
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *apiKeyHandler) CreateAPIKey(ctx *gin.Context) {
+	/**
+	 * 1. Map the name and scopes into CreateAPIKeyInput
+	 * 2. Generate the key, only its hash is stored
+	 * 3. Respond with the key, the only time it is shown
+	 */
+
+	var input apikey.CreateAPIKeyInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		abortWithError(ctx, "Failed to create API key", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	newAPIKey, key, err := h.service.CreateAPIKey(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to create API key", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"API key successfully created, copy it now as it will not be shown again",
+		http.StatusOK,
+		"success",
+		apikey.FormatAPIKey(newAPIKey, key),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *apiKeyHandler) RevokeAPIKey(ctx *gin.Context) {
+	var input apikey.GetAPIKeyInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to revoke API key", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	revokedAPIKey, err := h.service.RevokeAPIKey(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to revoke API key", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"API key successfully revoked",
+		http.StatusOK,
+		"success",
+		apikey.FormatAPIKey(revokedAPIKey, ""),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"backer/apperror"
 	"backer/campaign"
 	"backer/helper"
+	"backer/storage"
+	"backer/upload"
 	"backer/user"
-	"fmt"
 	"net/http"
+	"path"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -13,10 +16,12 @@ import (
 
 type campaignHandler struct {
 	service campaign.Service
+	store   storage.Store
+	urls    *storage.URLBuilder
 }
 
-func NewCampaignHandler(service campaign.Service) *campaignHandler {
-	return &campaignHandler{service}
+func NewCampaignHandler(service campaign.Service, store storage.Store, urls *storage.URLBuilder) *campaignHandler {
+	return &campaignHandler{service, store, urls}
 }
 
 func (h *campaignHandler) GetCampaigns(ctx *gin.Context) {
@@ -24,15 +29,7 @@ func (h *campaignHandler) GetCampaigns(ctx *gin.Context) {
 
 	campaigns, err := h.service.GetCampaigns(userID)
 	if err != nil {
-		errorMessage := gin.H{"errors": err.Error()}
-
-		response := helper.APIResponse(
-			"Error to get campaigns",
-			http.StatusInternalServerError,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusInternalServerError, response)
+		abortWithError(ctx, "Error to get campaigns", err)
 		return
 	}
 
@@ -40,7 +37,7 @@ func (h *campaignHandler) GetCampaigns(ctx *gin.Context) {
 		"List of campaigns",
 		http.StatusOK,
 		"success",
-		campaign.FormatCampaigns(campaigns),
+		campaign.FormatCampaigns(campaigns, h.urls),
 	)
 	ctx.JSON(http.StatusOK, response)
 }
@@ -55,30 +52,13 @@ func (h *campaignHandler) GetCampaign(ctx *gin.Context) {
 	var input campaign.GetCampaignInput
 
 	if err := ctx.ShouldBindUri(&input); err != nil {
-		errors := helper.FormatValidationError(err)
-		errorMessage := gin.H{"errors": errors}
-
-		response := helper.APIResponse(
-			"Failed to get detail campaign",
-			http.StatusBadRequest,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusBadRequest, response)
+		abortWithError(ctx, "Failed to get detail campaign", apperror.InvalidInput(err))
 		return
 	}
 
 	campaignDetail, err := h.service.GetCampaignByID(input)
 	if err != nil {
-		errorMessage := gin.H{"errors": err.Error()}
-
-		response := helper.APIResponse(
-			"Failed to get detail campaign",
-			http.StatusInternalServerError,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusInternalServerError, response)
+		abortWithError(ctx, "Failed to get detail campaign", err)
 		return
 	}
 
@@ -86,7 +66,7 @@ func (h *campaignHandler) GetCampaign(ctx *gin.Context) {
 		"Campaign detail",
 		http.StatusOK,
 		"success",
-		campaign.FormatCampaignDetail(campaignDetail),
+		campaign.FormatCampaignDetail(campaignDetail, h.urls),
 	)
 	ctx.JSON(http.StatusOK, response)
 }
@@ -95,16 +75,7 @@ func (h *campaignHandler) CreateCampaign(ctx *gin.Context) {
 	var input campaign.CreateCampaignInput
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		errors := helper.FormatValidationError(err)
-		errorMessage := gin.H{"errors": errors}
-
-		response := helper.APIResponse(
-			"Failed to create campaign",
-			http.StatusUnprocessableEntity,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusUnprocessableEntity, response)
+		abortWithError(ctx, "Failed to create campaign", apperror.InvalidInput(err))
 		return
 	}
 
@@ -114,22 +85,15 @@ func (h *campaignHandler) CreateCampaign(ctx *gin.Context) {
 
 	newCampaign, err := h.service.CreateCampaign(input)
 	if err != nil {
-		errorMessage := gin.H{"errors": err.Error()}
-
-		response := helper.APIResponse(
-			"Failed to create campaign",
-			http.StatusInternalServerError,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusInternalServerError, response)
+		abortWithError(ctx, "Failed to create campaign", err)
+		return
 	}
 
 	response := helper.APIResponse(
 		"Campaign successfully created",
 		http.StatusOK,
 		"success",
-		campaign.FormatCampaign(newCampaign),
+		campaign.FormatCampaign(newCampaign, h.urls),
 	)
 	ctx.JSON(http.StatusOK, response)
 }
@@ -138,32 +102,14 @@ func (h *campaignHandler) UpdateCampaign(ctx *gin.Context) {
 	var inputID campaign.GetCampaignInput
 
 	if err := ctx.ShouldBindUri(&inputID); err != nil {
-		errors := helper.FormatValidationError(err)
-		errorMessage := gin.H{"errors": errors}
-
-		response := helper.APIResponse(
-			"Failed to update campaign",
-			http.StatusBadRequest,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusBadRequest, response)
+		abortWithError(ctx, "Failed to update campaign", apperror.InvalidInput(err))
 		return
 	}
 
 	var inputData campaign.CreateCampaignInput
 
 	if err := ctx.ShouldBindJSON(&inputData); err != nil {
-		errors := helper.FormatValidationError(err)
-		errorMessage := gin.H{"errors": errors}
-
-		response := helper.APIResponse(
-			"Failed to update campaign",
-			http.StatusUnprocessableEntity,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusUnprocessableEntity, response)
+		abortWithError(ctx, "Failed to update campaign", apperror.InvalidInput(err))
 		return
 	}
 
@@ -173,22 +119,15 @@ func (h *campaignHandler) UpdateCampaign(ctx *gin.Context) {
 
 	updatedCampaign, err := h.service.UpdateCampaign(inputID, inputData)
 	if err != nil {
-		errorMessage := gin.H{"errors": err.Error()}
-
-		response := helper.APIResponse(
-			"Failed to update campaign",
-			http.StatusBadRequest,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusBadRequest, response)
+		abortWithError(ctx, "Failed to update campaign", err)
+		return
 	}
 
 	response := helper.APIResponse(
 		"Campaign successfully updated",
 		http.StatusOK,
 		"success",
-		campaign.FormatCampaign(updatedCampaign),
+		campaign.FormatCampaign(updatedCampaign, h.urls),
 	)
 	ctx.JSON(http.StatusOK, response)
 }
@@ -197,62 +136,42 @@ func (h *campaignHandler) UploadCampaignImage(ctx *gin.Context) {
 	var input campaign.CreateCampaignImageInput
 
 	if err := ctx.ShouldBind(&input); err != nil {
-		errors := helper.FormatValidationError(err)
-		errorMessage := gin.H{"errors": errors}
-
-		response := helper.APIResponse(
-			"Failed to upload campaign image",
-			http.StatusBadRequest,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusBadRequest, response)
+		abortWithError(ctx, "Failed to upload campaign image", apperror.InvalidInput(err))
 		return
 	}
 
 	file, err := ctx.FormFile("file")
 	if err != nil {
-		data := gin.H{"is_uploaded": false}
+		abortWithError(ctx, "Failed to upload campaign image", apperror.InvalidInput(err))
+		return
+	}
 
-		response := helper.APIResponse(
-			"Failed to upload campaign image",
-			http.StatusInternalServerError,
-			"error",
-			data,
-		)
-		ctx.JSON(http.StatusInternalServerError, response)
+	campaignImage, err := upload.ReadImage(file, upload.CampaignImageRules)
+	if err != nil {
+		abortWithError(ctx, "Failed to upload campaign image", err)
 		return
 	}
 
-	currentUser := ctx.MustGet("currentUser").(user.User)
+	fileName, err := upload.RandomFileName(upload.ProcessedExtension)
+	if err != nil {
+		abortWithError(ctx, "Failed to upload campaign image", err)
+		return
+	}
 
-	path := fmt.Sprintf("campaign-images/%d-%s", currentUser.ID, file.Filename)
+	currentUser := ctx.MustGet("currentUser").(user.User)
 
-	if ctx.SaveUploadedFile(file, path); err != nil {
-		data := gin.H{"is_uploaded": false}
+	key := path.Join(upload.CampaignImageDir, fileName)
 
-		response := helper.APIResponse(
-			"Failed to upload campaign image",
-			http.StatusInternalServerError,
-			"error",
-			data,
-		)
-		ctx.JSON(http.StatusInternalServerError, response)
+	if err := saveImage(h.store, key, campaignImage); err != nil {
+		abortWithError(ctx, "Failed to upload campaign image", err)
 		return
 	}
 
 	input.User = currentUser
 
-	if _, err := h.service.CreateCampaignImage(input, path); err != nil {
-		data := gin.H{"is_uploaded": false}
-
-		response := helper.APIResponse(
-			"Failed to upload campaign image",
-			http.StatusBadRequest,
-			"error",
-			data,
-		)
-		ctx.JSON(http.StatusBadRequest, response)
+	if _, err := h.service.CreateCampaignImage(input, key); err != nil {
+		removeImage(h.store, key)
+		abortWithError(ctx, "Failed to upload campaign image", err)
 		return
 	}
 
@@ -266,3 +185,356 @@ func (h *campaignHandler) UploadCampaignImage(ctx *gin.Context) {
 	)
 	ctx.JSON(http.StatusOK, response)
 }
+
+func (h *campaignHandler) DeleteCampaignImage(ctx *gin.Context) {
+	var input campaign.GetCampaignImageInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to delete campaign image", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	deletedImage, err := h.service.DeleteCampaignImage(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to delete campaign image", err)
+		return
+	}
+
+	removeImage(h.store, deletedImage.FileName)
+
+	response := helper.APIResponse(
+		"Campaign image successfully deleted",
+		http.StatusOK,
+		"success",
+		campaign.FormatCampaignImage(deletedImage, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignHandler) SetPrimaryCampaignImage(ctx *gin.Context) {
+	var input campaign.GetCampaignImageInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to set primary campaign image", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	primaryImage, err := h.service.SetPrimaryCampaignImage(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to set primary campaign image", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Primary campaign image successfully updated",
+		http.StatusOK,
+		"success",
+		campaign.FormatCampaignImage(primaryImage, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignHandler) ReorderCampaignImages(ctx *gin.Context) {
+	var inputID campaign.GetCampaignInput
+
+	if err := ctx.ShouldBindUri(&inputID); err != nil {
+		abortWithError(ctx, "Failed to reorder campaign images", apperror.InvalidInput(err))
+		return
+	}
+
+	var inputData campaign.ReorderCampaignImagesInput
+
+	if err := ctx.ShouldBindJSON(&inputData); err != nil {
+		abortWithError(ctx, "Failed to reorder campaign images", apperror.InvalidInput(err))
+		return
+	}
+
+	inputData.User = ctx.MustGet("currentUser").(user.User)
+
+	images, err := h.service.ReorderCampaignImages(inputID, inputData)
+	if err != nil {
+		abortWithError(ctx, "Failed to reorder campaign images", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign images successfully reordered",
+		http.StatusOK,
+		"success",
+		campaign.FormatCampaignImages(images, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignHandler) CreateCampaignFAQ(ctx *gin.Context) {
+	var inputID campaign.GetCampaignInput
+
+	if err := ctx.ShouldBindUri(&inputID); err != nil {
+		abortWithError(ctx, "Failed to create campaign FAQ", apperror.InvalidInput(err))
+		return
+	}
+
+	var inputData campaign.CreateCampaignFAQInput
+
+	if err := ctx.ShouldBindJSON(&inputData); err != nil {
+		abortWithError(ctx, "Failed to create campaign FAQ", apperror.InvalidInput(err))
+		return
+	}
+
+	inputData.User = ctx.MustGet("currentUser").(user.User)
+
+	newCampaignFAQ, err := h.service.CreateCampaignFAQ(inputID, inputData)
+	if err != nil {
+		abortWithError(ctx, "Failed to create campaign FAQ", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign FAQ successfully created",
+		http.StatusOK,
+		"success",
+		campaign.FormatCampaignFAQ(newCampaignFAQ),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignHandler) UpdateCampaignFAQ(ctx *gin.Context) {
+	var inputID campaign.GetCampaignFAQInput
+
+	if err := ctx.ShouldBindUri(&inputID); err != nil {
+		abortWithError(ctx, "Failed to update campaign FAQ", apperror.InvalidInput(err))
+		return
+	}
+
+	var inputData campaign.CreateCampaignFAQInput
+
+	if err := ctx.ShouldBindJSON(&inputData); err != nil {
+		abortWithError(ctx, "Failed to update campaign FAQ", apperror.InvalidInput(err))
+		return
+	}
+
+	inputData.User = ctx.MustGet("currentUser").(user.User)
+
+	updatedCampaignFAQ, err := h.service.UpdateCampaignFAQ(inputID, inputData)
+	if err != nil {
+		abortWithError(ctx, "Failed to update campaign FAQ", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign FAQ successfully updated",
+		http.StatusOK,
+		"success",
+		campaign.FormatCampaignFAQ(updatedCampaignFAQ),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignHandler) DeleteCampaignFAQ(ctx *gin.Context) {
+	var input campaign.GetCampaignFAQInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to delete campaign FAQ", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	deletedCampaignFAQ, err := h.service.DeleteCampaignFAQ(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to delete campaign FAQ", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign FAQ successfully deleted",
+		http.StatusOK,
+		"success",
+		campaign.FormatCampaignFAQ(deletedCampaignFAQ),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignHandler) ReorderCampaignFAQs(ctx *gin.Context) {
+	var inputID campaign.GetCampaignInput
+
+	if err := ctx.ShouldBindUri(&inputID); err != nil {
+		abortWithError(ctx, "Failed to reorder campaign FAQs", apperror.InvalidInput(err))
+		return
+	}
+
+	var inputData campaign.ReorderCampaignFAQsInput
+
+	if err := ctx.ShouldBindJSON(&inputData); err != nil {
+		abortWithError(ctx, "Failed to reorder campaign FAQs", apperror.InvalidInput(err))
+		return
+	}
+
+	inputData.User = ctx.MustGet("currentUser").(user.User)
+
+	campaignFAQs, err := h.service.ReorderCampaignFAQs(inputID, inputData)
+	if err != nil {
+		abortWithError(ctx, "Failed to reorder campaign FAQs", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign FAQs successfully reordered",
+		http.StatusOK,
+		"success",
+		campaign.FormatCampaignFAQs(campaignFAQs),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignHandler) DeleteCampaign(ctx *gin.Context) {
+	/**
+	 * 1. Get the campaign ID from the URI
+	 * 2. Soft delete the campaign, unless it has backers
+	 */
+
+	var input campaign.OwnedCampaignInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to delete campaign", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	deletedCampaign, err := h.service.DeleteCampaign(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to delete campaign", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign successfully deleted",
+		http.StatusOK,
+		"success",
+		campaign.FormatCampaign(deletedCampaign, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignHandler) ArchiveCampaign(ctx *gin.Context) {
+	var input campaign.OwnedCampaignInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to archive campaign", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	archivedCampaign, err := h.service.ArchiveCampaign(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to archive campaign", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign successfully archived",
+		http.StatusOK,
+		"success",
+		campaign.FormatCampaign(archivedCampaign, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignHandler) UnarchiveCampaign(ctx *gin.Context) {
+	var input campaign.OwnedCampaignInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to unarchive campaign", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	unarchivedCampaign, err := h.service.UnarchiveCampaign(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to unarchive campaign", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign successfully unarchived",
+		http.StatusOK,
+		"success",
+		campaign.FormatCampaign(unarchivedCampaign, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignHandler) PatchCampaign(ctx *gin.Context) {
+	/**
+	 * 1. Get the campaign ID from the URI
+	 * 2. Map the merge patch into PatchCampaignInput, fields left out of it
+	 *    stay nil and unchanged
+	 * 3. Pass both into service
+	 */
+
+	var inputID campaign.GetCampaignInput
+
+	if err := ctx.ShouldBindUri(&inputID); err != nil {
+		abortWithError(ctx, "Failed to update campaign", apperror.InvalidInput(err))
+		return
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		abortWithError(ctx, "Failed to update campaign", apperror.InvalidInput(err))
+		return
+	}
+
+	var inputData campaign.PatchCampaignInput
+
+	if err := helper.BindMergePatch(body, &inputData); err != nil {
+		abortWithError(ctx, "Failed to update campaign", apperror.InvalidInput(err))
+		return
+	}
+
+	inputData.User = ctx.MustGet("currentUser").(user.User)
+
+	updatedCampaign, err := h.service.PatchCampaign(inputID, inputData)
+	if err != nil {
+		abortWithError(ctx, "Failed to update campaign", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign successfully updated",
+		http.StatusOK,
+		"success",
+		campaign.FormatCampaign(updatedCampaign, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignHandler) GetCampaignRevisions(ctx *gin.Context) {
+	var input campaign.GetCampaignInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to get campaign revisions", apperror.InvalidInput(err))
+		return
+	}
+
+	revisions, err := h.service.GetCampaignRevisions(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to get campaign revisions", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"List of campaign revisions",
+		http.StatusOK,
+		"success",
+		campaign.FormatCampaignRevisions(revisions),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"backer/apperror"
+	"backer/campaignupdate"
+	"backer/helper"
+	"backer/storage"
+	"backer/upload"
+	"backer/user"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+)
+
+type campaignUpdateHandler struct {
+	service campaignupdate.Service
+	store   storage.Store
+	urls    *storage.URLBuilder
+}
+
+func NewCampaignUpdateHandler(service campaignupdate.Service, store storage.Store, urls *storage.URLBuilder) *campaignUpdateHandler {
+	return &campaignUpdateHandler{service, store, urls}
+}
+
+func (h *campaignUpdateHandler) GetCampaignUpdates(ctx *gin.Context) {
+	var input campaignupdate.GetCampaignUpdatesInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to get campaign updates", apperror.InvalidInput(err))
+		return
+	}
+
+	// Visitors can read public updates without logging in
+	input.User, _ = ctx.Value("currentUser").(user.User)
+
+	campaignUpdates, err := h.service.GetCampaignUpdates(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to get campaign updates", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"List of campaign updates",
+		http.StatusOK,
+		"success",
+		campaignupdate.FormatCampaignUpdates(campaignUpdates, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignUpdateHandler) GetCampaignUpdate(ctx *gin.Context) {
+	var input campaignupdate.GetCampaignUpdateInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to get campaign update", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User, _ = ctx.Value("currentUser").(user.User)
+
+	campaignUpdate, err := h.service.GetCampaignUpdate(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to get campaign update", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign update detail",
+		http.StatusOK,
+		"success",
+		campaignupdate.FormatCampaignUpdate(campaignUpdate, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignUpdateHandler) CreateCampaignUpdate(ctx *gin.Context) {
+	/**
+	 * 1. Get the campaign ID from the URI and the update from the body
+	 * 2. Save the update, checking the user owns the campaign
+	 * 3. When it is published, its backers are emailed in the background
+	 */
+
+	var inputID campaignupdate.GetCampaignUpdatesInput
+
+	if err := ctx.ShouldBindUri(&inputID); err != nil {
+		abortWithError(ctx, "Failed to create campaign update", apperror.InvalidInput(err))
+		return
+	}
+
+	var inputData campaignupdate.CreateCampaignUpdateInput
+
+	if err := ctx.ShouldBindJSON(&inputData); err != nil {
+		abortWithError(ctx, "Failed to create campaign update", apperror.InvalidInput(err))
+		return
+	}
+
+	inputData.User = ctx.MustGet("currentUser").(user.User)
+
+	newCampaignUpdate, err := h.service.CreateCampaignUpdate(inputID, inputData)
+	if err != nil {
+		abortWithError(ctx, "Failed to create campaign update", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign update successfully created",
+		http.StatusOK,
+		"success",
+		campaignupdate.FormatCampaignUpdate(newCampaignUpdate, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignUpdateHandler) UpdateCampaignUpdate(ctx *gin.Context) {
+	var inputID campaignupdate.GetCampaignUpdateInput
+
+	if err := ctx.ShouldBindUri(&inputID); err != nil {
+		abortWithError(ctx, "Failed to update campaign update", apperror.InvalidInput(err))
+		return
+	}
+
+	var inputData campaignupdate.CreateCampaignUpdateInput
+
+	if err := ctx.ShouldBindJSON(&inputData); err != nil {
+		abortWithError(ctx, "Failed to update campaign update", apperror.InvalidInput(err))
+		return
+	}
+
+	inputData.User = ctx.MustGet("currentUser").(user.User)
+
+	updatedCampaignUpdate, err := h.service.UpdateCampaignUpdate(inputID, inputData)
+	if err != nil {
+		abortWithError(ctx, "Failed to update campaign update", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign update successfully updated",
+		http.StatusOK,
+		"success",
+		campaignupdate.FormatCampaignUpdate(updatedCampaignUpdate, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignUpdateHandler) DeleteCampaignUpdate(ctx *gin.Context) {
+	var input campaignupdate.GetCampaignUpdateInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to delete campaign update", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	deletedCampaignUpdate, err := h.service.DeleteCampaignUpdate(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to delete campaign update", err)
+		return
+	}
+
+	for _, image := range deletedCampaignUpdate.CampaignUpdateImages {
+		removeImage(h.store, image.FileName)
+	}
+
+	response := helper.APIResponse(
+		"Campaign update successfully deleted",
+		http.StatusOK,
+		"success",
+		campaignupdate.FormatCampaignUpdate(deletedCampaignUpdate, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignUpdateHandler) UploadCampaignUpdateImage(ctx *gin.Context) {
+	var input campaignupdate.GetCampaignUpdateInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to upload campaign update image", apperror.InvalidInput(err))
+		return
+	}
+
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		abortWithError(ctx, "Failed to upload campaign update image", apperror.InvalidInput(err))
+		return
+	}
+
+	updateImage, err := upload.ReadImage(file, upload.CampaignImageRules)
+	if err != nil {
+		abortWithError(ctx, "Failed to upload campaign update image", err)
+		return
+	}
+
+	fileName, err := upload.RandomFileName(upload.ProcessedExtension)
+	if err != nil {
+		abortWithError(ctx, "Failed to upload campaign update image", err)
+		return
+	}
+
+	key := path.Join(upload.CampaignUpdateImageDir, fileName)
+
+	if err := saveImage(h.store, key, updateImage); err != nil {
+		abortWithError(ctx, "Failed to upload campaign update image", err)
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	newImage, err := h.service.CreateCampaignUpdateImage(input, key)
+	if err != nil {
+		removeImage(h.store, key)
+		abortWithError(ctx, "Failed to upload campaign update image", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Campaign update image successfully uploaded",
+		http.StatusOK,
+		"success",
+		campaignupdate.FormatCampaignUpdateImage(newImage, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *campaignUpdateHandler) DeleteCampaignUpdateImage(ctx *gin.Context) {
+	var input campaignupdate.GetCampaignUpdateImageInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to delete campaign update image", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	deletedImage, err := h.service.DeleteCampaignUpdateImage(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to delete campaign update image", err)
+		return
+	}
+
+	removeImage(h.store, deletedImage.FileName)
+
+	response := helper.APIResponse(
+		"Campaign update image successfully deleted",
+		http.StatusOK,
+		"success",
+		campaignupdate.FormatCampaignUpdateImage(deletedImage, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"backer/apperror"
+	"backer/comment"
+	"backer/helper"
+	"backer/storage"
+	"backer/user"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type commentHandler struct {
+	service comment.Service
+	urls    *storage.URLBuilder
+}
+
+func NewCommentHandler(service comment.Service, urls *storage.URLBuilder) *commentHandler {
+	return &commentHandler{service, urls}
+}
+
+func (h *commentHandler) GetComments(ctx *gin.Context) {
+	var input comment.GetCommentsInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to get comments", apperror.InvalidInput(err))
+		return
+	}
+
+	var page comment.PageInput
+
+	if err := ctx.ShouldBindQuery(&page); err != nil {
+		abortWithError(ctx, "Failed to get comments", apperror.InvalidInput(err))
+		return
+	}
+
+	// Visitors can read comments without logging in
+	input.User, _ = ctx.Value("currentUser").(user.User)
+
+	comments, err := h.service.GetComments(input, page)
+	if err != nil {
+		abortWithError(ctx, "Failed to get comments", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"List of comments",
+		http.StatusOK,
+		"success",
+		comment.FormatComments(comments, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *commentHandler) CreateComment(ctx *gin.Context) {
+	var inputID comment.GetCommentsInput
+
+	if err := ctx.ShouldBindUri(&inputID); err != nil {
+		abortWithError(ctx, "Failed to create comment", apperror.InvalidInput(err))
+		return
+	}
+
+	var inputData comment.CreateCommentInput
+
+	if err := ctx.ShouldBindJSON(&inputData); err != nil {
+		abortWithError(ctx, "Failed to create comment", apperror.InvalidInput(err))
+		return
+	}
+
+	inputData.User = ctx.MustGet("currentUser").(user.User)
+
+	newComment, err := h.service.CreateComment(inputID, inputData)
+	if err != nil {
+		abortWithError(ctx, "Failed to create comment", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Comment successfully created",
+		http.StatusOK,
+		"success",
+		comment.FormatComment(newComment, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *commentHandler) UpdateComment(ctx *gin.Context) {
+	var inputID comment.GetCommentInput
+
+	if err := ctx.ShouldBindUri(&inputID); err != nil {
+		abortWithError(ctx, "Failed to update comment", apperror.InvalidInput(err))
+		return
+	}
+
+	var inputData comment.UpdateCommentInput
+
+	if err := ctx.ShouldBindJSON(&inputData); err != nil {
+		abortWithError(ctx, "Failed to update comment", apperror.InvalidInput(err))
+		return
+	}
+
+	inputData.User = ctx.MustGet("currentUser").(user.User)
+
+	updatedComment, err := h.service.UpdateComment(inputID, inputData)
+	if err != nil {
+		abortWithError(ctx, "Failed to update comment", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Comment successfully updated",
+		http.StatusOK,
+		"success",
+		comment.FormatComment(updatedComment, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *commentHandler) DeleteComment(ctx *gin.Context) {
+	var input comment.GetCommentInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to delete comment", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	deletedComment, err := h.service.DeleteComment(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to delete comment", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Comment successfully deleted",
+		http.StatusOK,
+		"success",
+		comment.FormatComment(deletedComment, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *commentHandler) HideComment(ctx *gin.Context) {
+	var input comment.GetCommentInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to hide comment", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	hiddenComment, err := h.service.HideComment(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to hide comment", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Comment successfully hidden",
+		http.StatusOK,
+		"success",
+		comment.FormatComment(hiddenComment, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *commentHandler) UnhideComment(ctx *gin.Context) {
+	var input comment.GetCommentInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to unhide comment", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	unhiddenComment, err := h.service.UnhideComment(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to unhide comment", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Comment successfully unhidden",
+		http.StatusOK,
+		"success",
+		comment.FormatComment(unhiddenComment, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *commentHandler) PinComment(ctx *gin.Context) {
+	var input comment.GetCommentInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to pin comment", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	pinnedComment, err := h.service.PinComment(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to pin comment", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Comment successfully pinned",
+		http.StatusOK,
+		"success",
+		comment.FormatComment(pinnedComment, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *commentHandler) UnpinComment(ctx *gin.Context) {
+	var input comment.GetCommentInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to unpin comment", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+
+	unpinnedComment, err := h.service.UnpinComment(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to unpin comment", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Comment successfully unpinned",
+		http.StatusOK,
+		"success",
+		comment.FormatComment(unpinnedComment, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"backer/apperror"
+	"backer/helper"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// NewRouter creates the engine every route is registered on. Only the
+// proxies in trustedProxies may set the client IP through X-Forwarded-For,
+// otherwise anyone could pick the IP the login limiter counts failures of.
+func NewRouter(trustedProxies []string) (*gin.Engine, error) {
+	router := gin.Default()
+
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+
+	router.Use(ErrorHandler())
+
+	return router, nil
+}
+
+// ErrorHandler renders the last error attached to the context by a handler
+// or middleware. The error's meta, when it is a string, becomes the
+// response message.
+func ErrorHandler() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		if len(ctx.Errors) == 0 || ctx.Writer.Written() {
+			return
+		}
+
+		lastError := ctx.Errors.Last()
+
+		code := apperror.CodeOf(lastError.Err)
+		status := apperror.HTTPStatus(code)
+
+		message, ok := lastError.Meta.(string)
+		if !ok {
+			message = apperror.MessageOf(lastError.Err)
+		}
+
+		var errorMessage gin.H
+
+		if code == apperror.CodeValidation {
+			translator := helper.Translator(ctx.GetHeader("Accept-Language"))
+			errorMessage = gin.H{"errors": validationErrors(lastError.Err, translator)}
+		} else {
+			errorMessage = gin.H{"errors": apperror.MessageOf(lastError.Err)}
+		}
+
+		response := helper.APIErrorResponse(message, status, string(code), errorMessage)
+		ctx.JSON(status, response)
+	}
+}
+
+func validationErrors(err error, translator ut.Translator) []helper.ValidationError {
+	var appError *apperror.Error
+	if errors.As(err, &appError) && appError.Err != nil {
+		return helper.FormatValidationError(appError.Err, translator)
+	}
+
+	return []helper.ValidationError{{Message: apperror.MessageOf(err)}}
+}
+
+func abortWithError(ctx *gin.Context, message string, err error) {
+	ctx.Error(err).SetMeta(message)
+	ctx.Abort()
+}
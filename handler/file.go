@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"backer/storage"
+	"backer/upload"
+	"bytes"
+	"log"
+)
+
+// saveImage processes an uploaded image and stores each of its renditions
+// next to key.
+func saveImage(store storage.Store, key string, img upload.Image) error {
+	renditions, err := upload.Process(img)
+	if err != nil {
+		return err
+	}
+
+	for name, data := range renditions {
+		if err := store.Put(upload.RenditionPath(key, name), bytes.NewReader(data), "image/jpeg"); err != nil {
+			removeImage(store, key)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeImage deletes every rendition of an image which is no longer
+// referenced. Failures are only logged since the database is already
+// consistent at this point.
+func removeImage(store storage.Store, key string) {
+	for _, renditionKey := range upload.RenditionPaths(key) {
+		if err := store.Delete(renditionKey); err != nil {
+			log.Printf("failed to remove file %s: %v", renditionKey, err)
+		}
+	}
+}
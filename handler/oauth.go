@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"backer/apperror"
+	"backer/auth"
+	"backer/oauth"
+	"backer/session"
+	"backer/storage"
+	"backer/user"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oauthNonceCookie keeps the nonce of the state in the browser which
+// started the login, only that browser can complete it
+const oauthNonceCookie = "oauth_nonce"
+
+type oauthHandler struct {
+	providers      map[string]oauth.Provider
+	userService    user.Service
+	sessionService session.Service
+	authService    auth.Service
+	urls           *storage.URLBuilder
+}
+
+func NewOAuthHandler(providers []oauth.Provider, userService user.Service, sessionService session.Service, authService auth.Service, urls *storage.URLBuilder) *oauthHandler {
+	providersByName := map[string]oauth.Provider{}
+	for _, provider := range providers {
+		providersByName[provider.Name()] = provider
+	}
+
+	return &oauthHandler{providersByName, userService, sessionService, authService, urls}
+}
+
+func (h *oauthHandler) Authorize(ctx *gin.Context) {
+	/**
+	 * 1. Sign a state bound to the provider and keep its nonce in a cookie
+	 * 2. Redirect the user to the provider to grant access
+	 */
+
+	provider, ok := h.provider(ctx)
+	if !ok {
+		return
+	}
+
+	state, nonce, err := h.authService.GenerateOAuthState(provider.Name())
+	if err != nil {
+		abortWithError(ctx, "Login failed", err)
+		return
+	}
+
+	authCodeURL, err := provider.AuthCodeURL(state)
+	if err != nil {
+		abortWithError(ctx, "Login failed", err)
+		return
+	}
+
+	// The provider redirects back with a top-level GET, which Lax cookies
+	// are sent with. The path covers the callback of this provider only.
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oauthNonceCookie, nonce, 10*60, nonceCookiePath(provider), "", isSecureRequest(ctx), true)
+
+	ctx.Redirect(http.StatusFound, authCodeURL)
+}
+
+func (h *oauthHandler) Callback(ctx *gin.Context) {
+	/**
+	 * 1. Check the state the provider redirected back with belongs to this browser
+	 * 2. Exchange the authorization code for the user's identity
+	 * 3. Log in the linked user, linking or registering one the first time
+	 */
+
+	provider, ok := h.provider(ctx)
+	if !ok {
+		return
+	}
+
+	var input oauth.CallbackInput
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		abortWithError(ctx, "Login failed", apperror.InvalidInput(err))
+		return
+	}
+
+	if input.Error != "" {
+		abortWithError(ctx, "Login failed", oauth.ErrLoginFailed)
+		return
+	}
+
+	nonce, _ := ctx.Cookie(oauthNonceCookie)
+
+	// The nonce is single use, whatever the outcome
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oauthNonceCookie, "", -1, nonceCookiePath(provider), "", isSecureRequest(ctx), true)
+
+	if err := h.authService.ValidateOAuthState(input.State, provider.Name(), nonce); err != nil {
+		abortWithError(ctx, "Login failed", oauth.ErrLoginFailed)
+		return
+	}
+
+	identity, err := provider.Exchange(input.Code)
+	if err != nil {
+		log.Printf("failed to exchange %s authorization code: %v", provider.Name(), err)
+		abortWithError(ctx, "Login failed", oauth.ErrLoginFailed)
+		return
+	}
+
+	loggedInUser, err := h.userService.LoginWithProvider(user.ProviderLoginInput{
+		Provider:      identity.Provider,
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+		Name:          identity.Name,
+	})
+	if err != nil {
+		abortWithError(ctx, "Login failed", err)
+		return
+	}
+
+	respondWithLogin(ctx, h.sessionService, h.authService, h.urls, loggedInUser)
+}
+
+func (h *oauthHandler) provider(ctx *gin.Context) (oauth.Provider, bool) {
+	var input oauth.ProviderInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Login failed", apperror.InvalidInput(err))
+		return nil, false
+	}
+
+	provider, ok := h.providers[input.Provider]
+	if !ok {
+		abortWithError(ctx, "Login failed", oauth.ErrProviderNotFound)
+		return nil, false
+	}
+
+	return provider, true
+}
+
+// nonceCookiePath scopes the nonce cookie to the endpoints of the provider.
+func nonceCookiePath(provider oauth.Provider) string {
+	return "/api/v1/oauth/" + provider.Name()
+}
+
+// isSecureRequest reports whether the client reached the API over HTTPS,
+// directly or through a proxy terminating TLS.
+func isSecureRequest(ctx *gin.Context) bool {
+	return ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https"
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"backer/apperror"
+	"backer/helper"
+	"backer/session"
+	"backer/user"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type sessionHandler struct {
+	service session.Service
+}
+
+func NewSessionHandler(service session.Service) *sessionHandler {
+	return &sessionHandler{service}
+}
+
+func (h *sessionHandler) GetSessions(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(user.User)
+	currentSession := ctx.MustGet("currentSession").(session.Session)
+
+	sessions, err := h.service.GetSessions(currentUser.ID)
+	if err != nil {
+		abortWithError(ctx, "Failed to get sessions", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"List of sessions",
+		http.StatusOK,
+		"success",
+		session.FormatSessions(sessions, currentSession.ID),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *sessionHandler) RevokeSession(ctx *gin.Context) {
+	/**
+	 * 1. Get the session ID from the URI
+	 * 2. Revoke it if it belongs to the current user, revoking the current
+	 *    session logs the user out
+	 */
+
+	var input session.GetSessionInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to revoke session", apperror.InvalidInput(err))
+		return
+	}
+
+	input.User = ctx.MustGet("currentUser").(user.User)
+	currentSession := ctx.MustGet("currentSession").(session.Session)
+
+	revokedSession, err := h.service.RevokeSession(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to revoke session", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Session successfully revoked",
+		http.StatusOK,
+		"success",
+		session.FormatSession(revokedSession, currentSession.ID),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+// RevokeSessions signs the user out of every other device, keeping the
+// session the request is made with.
+func (h *sessionHandler) RevokeSessions(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(user.User)
+	currentSession := ctx.MustGet("currentSession").(session.Session)
+
+	revoked, err := h.service.RevokeSessions(currentUser.ID, currentSession.ID)
+	if err != nil {
+		abortWithError(ctx, "Failed to revoke sessions", err)
+		return
+	}
+
+	data := gin.H{"revoked_count": revoked}
+
+	response := helper.APIResponse("Other sessions successfully revoked", http.StatusOK, "success", data)
+	ctx.JSON(http.StatusOK, response)
+}
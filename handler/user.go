@@ -1,22 +1,30 @@
 package handler
 
 import (
+	"backer/apikey"
+	"backer/apperror"
 	"backer/auth"
 	"backer/helper"
+	"backer/session"
+	"backer/storage"
+	"backer/upload"
 	"backer/user"
-	"fmt"
 	"net/http"
+	"path"
 
 	"github.com/gin-gonic/gin"
 )
 
 type userHandler struct {
-	userService user.Service
-	authService auth.Service
+	userService    user.Service
+	sessionService session.Service
+	authService    auth.Service
+	store          storage.Store
+	urls           *storage.URLBuilder
 }
 
-func NewUserHandler(userService user.Service, authService auth.Service) *userHandler {
-	return &userHandler{userService, authService}
+func NewUserHandler(userService user.Service, sessionService session.Service, authService auth.Service, store storage.Store, urls *storage.URLBuilder) *userHandler {
+	return &userHandler{userService, sessionService, authService, store, urls}
 }
 
 func (h *userHandler) RegisterUser(ctx *gin.Context) {
@@ -30,44 +38,19 @@ func (h *userHandler) RegisterUser(ctx *gin.Context) {
 
 	err := ctx.ShouldBindJSON(&input)
 	if err != nil {
-		errors := helper.FormatValidationError(err)
-		errorMessage := gin.H{"errors": errors}
-
-		response := helper.APIResponse(
-			"Register account failed",
-			http.StatusUnprocessableEntity,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusUnprocessableEntity, response)
+		abortWithError(ctx, "Register account failed", apperror.InvalidInput(err))
 		return
 	}
 
 	newUser, err := h.userService.RegisterUser(input)
 	if err != nil {
-		errorMessage := gin.H{"errors": err.Error()}
-
-		response := helper.APIResponse(
-			"Register account failed",
-			http.StatusInternalServerError,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusInternalServerError, response)
+		abortWithError(ctx, "Register account failed", err)
 		return
 	}
 
-	token, err := h.authService.GenerateToken(newUser.ID)
+	token, err := startSession(ctx, h.sessionService, h.authService, newUser.ID)
 	if err != nil {
-		errorMessage := gin.H{"errors": err.Error()}
-
-		response := helper.APIResponse(
-			"Register account failed",
-			http.StatusInternalServerError,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusInternalServerError, response)
+		abortWithError(ctx, "Register account failed", err)
 		return
 	}
 
@@ -75,7 +58,7 @@ func (h *userHandler) RegisterUser(ctx *gin.Context) {
 		"Account has been registered",
 		http.StatusOK,
 		"success",
-		user.FormatUser(newUser, token),
+		user.FormatUser(newUser, token, h.urls),
 	)
 	ctx.JSON(http.StatusOK, response)
 }
@@ -92,44 +75,57 @@ func (h *userHandler) Login(ctx *gin.Context) {
 	var input user.LoginInput
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		errors := helper.FormatValidationError(err)
-		errorMessage := gin.H{"errors": errors}
-
-		response := helper.APIResponse(
-			"Login failed",
-			http.StatusUnprocessableEntity,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusUnprocessableEntity, response)
+		abortWithError(ctx, "Login failed", apperror.InvalidInput(err))
 		return
 	}
 
+	input.IPAddress = ctx.ClientIP()
+
 	loggedInUser, err := h.userService.Login(input)
 	if err != nil {
-		errorMessage := gin.H{"errors": err.Error()}
-
-		response := helper.APIResponse(
-			"Login failed",
-			http.StatusUnprocessableEntity,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusUnprocessableEntity, response)
+		abortWithError(ctx, "Login failed", err)
 		return
 	}
 
-	token, err := h.authService.GenerateToken(loggedInUser.ID)
+	respondWithLogin(ctx, h.sessionService, h.authService, h.urls, loggedInUser)
+}
+
+// startSession records a session for the device the request comes from and
+// signs a token for it.
+func startSession(ctx *gin.Context, sessionService session.Service, authService auth.Service, userID int) (string, error) {
+	newSession, err := sessionService.CreateSession(session.CreateSessionInput{
+		UserID:    userID,
+		UserAgent: ctx.Request.UserAgent(),
+		IPAddress: ctx.ClientIP(),
+	})
 	if err != nil {
-		errorMessage := gin.H{"errors": err.Error()}
+		return "", err
+	}
+
+	return authService.GenerateToken(userID, newSession.ID)
+}
 
-		response := helper.APIResponse(
-			"Login failed",
-			http.StatusInternalServerError,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusInternalServerError, response)
+// respondWithLogin hands out the session token for a user who passed the
+// first factor. With two-factor authentication enabled, the client instead
+// gets a short-lived token to finish the login on "/sessions/2fa".
+func respondWithLogin(ctx *gin.Context, sessionService session.Service, authService auth.Service, urls *storage.URLBuilder, loggedInUser user.User) {
+	if loggedInUser.TwoFactorEnabledAt != nil {
+		twoFactorToken, err := authService.GenerateTwoFactorToken(loggedInUser.ID)
+		if err != nil {
+			abortWithError(ctx, "Login failed", err)
+			return
+		}
+
+		data := gin.H{"two_factor_required": true, "two_factor_token": twoFactorToken}
+
+		response := helper.APIResponse("Two-factor authentication code required", http.StatusOK, "success", data)
+		ctx.JSON(http.StatusOK, response)
+		return
+	}
+
+	token, err := startSession(ctx, sessionService, authService, loggedInUser.ID)
+	if err != nil {
+		abortWithError(ctx, "Login failed", err)
 		return
 	}
 
@@ -137,7 +133,7 @@ func (h *userHandler) Login(ctx *gin.Context) {
 		"Successfully logged in",
 		http.StatusOK,
 		"success",
-		user.FormatUser(loggedInUser, token),
+		user.FormatUser(loggedInUser, token, urls),
 	)
 	ctx.JSON(http.StatusOK, response)
 }
@@ -153,30 +149,13 @@ func (h *userHandler) CheckEmailAvailability(ctx *gin.Context) {
 	var input user.CheckEmailInput
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
-		errors := helper.FormatValidationError(err)
-		errorMessage := gin.H{"errors": errors}
-
-		response := helper.APIResponse(
-			"Email checking failed",
-			http.StatusUnprocessableEntity,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusUnprocessableEntity, response)
+		abortWithError(ctx, "Email checking failed", apperror.InvalidInput(err))
 		return
 	}
 
 	isEmailAvailable, err := h.userService.IsEmailAvailable(input)
 	if err != nil {
-		errorMessage := gin.H{"errors": err.Error()}
-
-		response := helper.APIResponse(
-			"Email checking failed",
-			http.StatusInternalServerError,
-			"error",
-			errorMessage,
-		)
-		ctx.JSON(http.StatusInternalServerError, response)
+		abortWithError(ctx, "Email checking failed", err)
 		return
 	}
 
@@ -197,7 +176,7 @@ func (h *userHandler) CheckEmailAvailability(ctx *gin.Context) {
 func (h *userHandler) UploadAvatar(ctx *gin.Context) {
 	/**
 	 * 1. Get 'avatar' input form from client
-	 * 2. Store the image into "images/"
+	 * 2. Store the image renditions under "images/"
 	 * 3. Save input into database via service
 	 *      i. Get id of client from JWT token
 	 *      ii. Get user data with the id, then
@@ -206,49 +185,42 @@ func (h *userHandler) UploadAvatar(ctx *gin.Context) {
 
 	file, err := ctx.FormFile("avatar")
 	if err != nil {
-		data := gin.H{"is_uploaded": false}
+		abortWithError(ctx, "Failed to upload avatar image", apperror.InvalidInput(err))
+		return
+	}
 
-		response := helper.APIResponse(
-			"Failed to upload avatar image",
-			http.StatusBadRequest,
-			"error",
-			data,
-		)
-		ctx.JSON(http.StatusBadRequest, response)
+	avatar, err := upload.ReadImage(file, upload.AvatarRules)
+	if err != nil {
+		abortWithError(ctx, "Failed to upload avatar image", err)
+		return
+	}
+
+	fileName, err := upload.RandomFileName(upload.ProcessedExtension)
+	if err != nil {
+		abortWithError(ctx, "Failed to upload avatar image", err)
 		return
 	}
 
 	// Should be got from JWT token
 	currentUser := ctx.MustGet("currentUser").(user.User)
 
-	path := fmt.Sprintf("images/%d-%s", currentUser.ID, file.Filename)
-
-	if ctx.SaveUploadedFile(file, path); err != nil {
-		data := gin.H{"is_uploaded": false}
+	key := path.Join(upload.AvatarDir, fileName)
 
-		response := helper.APIResponse(
-			"Failed to upload avatar image",
-			http.StatusInternalServerError,
-			"error",
-			data,
-		)
-		ctx.JSON(http.StatusInternalServerError, response)
+	if err := saveImage(h.store, key, avatar); err != nil {
+		abortWithError(ctx, "Failed to upload avatar image", err)
 		return
 	}
 
-	if _, err := h.userService.SaveAvatar(currentUser.ID, path); err != nil {
-		data := gin.H{"is_uploaded": false}
-
-		response := helper.APIResponse(
-			"Failed to upload avatar image",
-			http.StatusInternalServerError,
-			"error",
-			data,
-		)
-		ctx.JSON(http.StatusInternalServerError, response)
+	if _, err := h.userService.SaveAvatar(currentUser.ID, key); err != nil {
+		removeImage(h.store, key)
+		abortWithError(ctx, "Failed to upload avatar image", err)
 		return
 	}
 
+	if currentUser.AvatarFileName != "" {
+		removeImage(h.store, currentUser.AvatarFileName)
+	}
+
 	data := gin.H{"is_uploaded": true}
 
 	response := helper.APIResponse(
@@ -259,3 +231,304 @@ func (h *userHandler) UploadAvatar(ctx *gin.Context) {
 	)
 	ctx.JSON(http.StatusOK, response)
 }
+
+func (h *userHandler) GetProfile(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(user.User)
+
+	response := helper.APIResponse(
+		"User profile",
+		http.StatusOK,
+		"success",
+		user.FormatUser(currentUser, "", h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *userHandler) UpdateProfile(ctx *gin.Context) {
+	var input user.UpdateProfileInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		abortWithError(ctx, "Failed to update profile", apperror.InvalidInput(err))
+		return
+	}
+
+	currentUser := ctx.MustGet("currentUser").(user.User)
+
+	// A leaked key must not be enough to take the account over
+	if _, ok := ctx.Get("currentAPIKey"); ok && input.Email != currentUser.Email {
+		abortWithError(ctx, "Failed to update profile", apikey.ErrEmailChange)
+		return
+	}
+
+	updatedUser, err := h.userService.UpdateProfile(currentUser.ID, input)
+	if err != nil {
+		abortWithError(ctx, "Failed to update profile", err)
+		return
+	}
+
+	metaMessage := "Profile successfully updated"
+	if updatedUser.UnconfirmedEmail != "" && updatedUser.UnconfirmedEmail != currentUser.UnconfirmedEmail {
+		metaMessage = "Profile successfully updated, check the new email address to confirm it"
+	}
+
+	response := helper.APIResponse(
+		metaMessage,
+		http.StatusOK,
+		"success",
+		user.FormatUser(updatedUser, "", h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *userHandler) ConfirmEmail(ctx *gin.Context) {
+	var input user.ConfirmEmailInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		abortWithError(ctx, "Email verification failed", apperror.InvalidInput(err))
+		return
+	}
+
+	confirmedUser, err := h.userService.ConfirmEmail(input)
+	if err != nil {
+		abortWithError(ctx, "Email verification failed", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Email successfully verified",
+		http.StatusOK,
+		"success",
+		user.FormatUser(confirmedUser, "", h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *userHandler) ChangePassword(ctx *gin.Context) {
+	var input user.ChangePasswordInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		abortWithError(ctx, "Failed to change password", apperror.InvalidInput(err))
+		return
+	}
+
+	currentUser := ctx.MustGet("currentUser").(user.User)
+
+	updatedUser, err := h.userService.ChangePassword(currentUser.ID, input)
+	if err != nil {
+		abortWithError(ctx, "Failed to change password", err)
+		return
+	}
+
+	// Every session is signed out, hand the client a fresh one
+	if _, err := h.sessionService.RevokeSessions(updatedUser.ID, 0); err != nil {
+		abortWithError(ctx, "Failed to change password", err)
+		return
+	}
+
+	token, err := startSession(ctx, h.sessionService, h.authService, updatedUser.ID)
+	if err != nil {
+		abortWithError(ctx, "Failed to change password", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Password successfully changed",
+		http.StatusOK,
+		"success",
+		user.FormatUser(updatedUser, token, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *userHandler) RequestPasswordReset(ctx *gin.Context) {
+	var input user.RequestPasswordResetInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		abortWithError(ctx, "Failed to request password reset", apperror.InvalidInput(err))
+		return
+	}
+
+	if err := h.userService.RequestPasswordReset(input); err != nil {
+		abortWithError(ctx, "Failed to request password reset", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"If the email is registered, a password reset link has been sent to it",
+		http.StatusOK,
+		"success",
+		nil,
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *userHandler) ResetPassword(ctx *gin.Context) {
+	var input user.ResetPasswordInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		abortWithError(ctx, "Failed to reset password", apperror.InvalidInput(err))
+		return
+	}
+
+	updatedUser, err := h.userService.ResetPassword(input)
+	if err != nil {
+		abortWithError(ctx, "Failed to reset password", err)
+		return
+	}
+
+	if _, err := h.sessionService.RevokeSessions(updatedUser.ID, 0); err != nil {
+		abortWithError(ctx, "Failed to reset password", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Password successfully reset",
+		http.StatusOK,
+		"success",
+		nil,
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *userHandler) ResendEmailVerification(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(user.User)
+
+	if err := h.userService.ResendEmailVerification(currentUser.ID); err != nil {
+		abortWithError(ctx, "Failed to resend email verification", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Email verification has been sent",
+		http.StatusOK,
+		"success",
+		nil,
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *userHandler) UnlockUser(ctx *gin.Context) {
+	var input user.GetUserInput
+
+	if err := ctx.ShouldBindUri(&input); err != nil {
+		abortWithError(ctx, "Failed to unlock user", apperror.InvalidInput(err))
+		return
+	}
+
+	unlockedUser, err := h.userService.UnlockUser(input.ID)
+	if err != nil {
+		abortWithError(ctx, "Failed to unlock user", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"User successfully unlocked",
+		http.StatusOK,
+		"success",
+		user.FormatUser(unlockedUser, "", h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *userHandler) VerifyTwoFactor(ctx *gin.Context) {
+	var input user.VerifyTwoFactorInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		abortWithError(ctx, "Login failed", apperror.InvalidInput(err))
+		return
+	}
+
+	input.IPAddress = ctx.ClientIP()
+
+	loggedInUser, err := h.userService.VerifyTwoFactor(input)
+	if err != nil {
+		abortWithError(ctx, "Login failed", err)
+		return
+	}
+
+	token, err := startSession(ctx, h.sessionService, h.authService, loggedInUser.ID)
+	if err != nil {
+		abortWithError(ctx, "Login failed", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Successfully logged in",
+		http.StatusOK,
+		"success",
+		user.FormatUser(loggedInUser, token, h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *userHandler) SetUpTwoFactor(ctx *gin.Context) {
+	currentUser := ctx.MustGet("currentUser").(user.User)
+
+	_, provisioningURI, err := h.userService.SetUpTwoFactor(currentUser.ID)
+	if err != nil {
+		abortWithError(ctx, "Failed to set up two-factor authentication", err)
+		return
+	}
+
+	data := gin.H{"provisioning_uri": provisioningURI}
+
+	response := helper.APIResponse(
+		"Scan the provisioning URI with an authenticator app, then confirm it with a code",
+		http.StatusOK,
+		"success",
+		data,
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *userHandler) EnableTwoFactor(ctx *gin.Context) {
+	var input user.TwoFactorCodeInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		abortWithError(ctx, "Failed to enable two-factor authentication", apperror.InvalidInput(err))
+		return
+	}
+
+	currentUser := ctx.MustGet("currentUser").(user.User)
+
+	recoveryCodes, err := h.userService.EnableTwoFactor(currentUser.ID, input)
+	if err != nil {
+		abortWithError(ctx, "Failed to enable two-factor authentication", err)
+		return
+	}
+
+	data := gin.H{"recovery_codes": recoveryCodes}
+
+	response := helper.APIResponse(
+		"Two-factor authentication enabled, store the recovery codes somewhere safe",
+		http.StatusOK,
+		"success",
+		data,
+	)
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (h *userHandler) DisableTwoFactor(ctx *gin.Context) {
+	var input user.TwoFactorCodeInput
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		abortWithError(ctx, "Failed to disable two-factor authentication", apperror.InvalidInput(err))
+		return
+	}
+
+	currentUser := ctx.MustGet("currentUser").(user.User)
+
+	updatedUser, err := h.userService.DisableTwoFactor(currentUser.ID, input)
+	if err != nil {
+		abortWithError(ctx, "Failed to disable two-factor authentication", err)
+		return
+	}
+
+	response := helper.APIResponse(
+		"Two-factor authentication disabled",
+		http.StatusOK,
+		"success",
+		user.FormatUser(updatedUser, "", h.urls),
+	)
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"backer/throttle"
+	"backer/user"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unknownUserRepository knows no user at all, so every login fails.
+type unknownUserRepository struct {
+	user.Repository
+}
+
+func (r unknownUserRepository) FindByEmail(email string) (user.User, error) {
+	return user.User{}, user.ErrUserNotFound
+}
+
+func TestLoginLimitIgnoresForgedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limiter := throttle.NewLimiter(3, time.Minute, time.Hour)
+	userService := user.NewService(unknownUserRepository{}, nil, nil, user.NewBcryptHasher(4), limiter)
+
+	router, err := NewRouter(nil)
+	if err != nil {
+		t.Fatalf("NewRouter() error = %v", err)
+	}
+	router.POST("/api/v1/sessions", NewUserHandler(userService, nil, nil, nil, nil).Login)
+
+	login := func(forwardedFor string) int {
+		request := httptest.NewRequest(http.MethodPost, "/api/v1/sessions", strings.NewReader(`{"email":"ada@example.com","password":"wrong password"}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Forwarded-For", forwardedFor)
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, request)
+
+		return recorder.Code
+	}
+
+	// Each attempt claims to come from another address
+	for i, forwardedFor := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+		if code := login(forwardedFor); code != http.StatusUnauthorized {
+			t.Fatalf("login %d = %d, want %d", i+1, code, http.StatusUnauthorized)
+		}
+	}
+
+	if code := login("198.51.100.4"); code != http.StatusTooManyRequests {
+		t.Errorf("login with a fresh X-Forwarded-For = %d, want %d", code, http.StatusTooManyRequests)
+	}
+}
+
+func TestNewRouterTrustsConfiguredProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		want           string
+	}{
+		{"no proxy", nil, "192.0.2.1"},
+		{"other proxy", []string{"10.0.0.0/8"}, "192.0.2.1"},
+		{"trusted proxy", []string{"192.0.2.0/24"}, "198.51.100.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			router, err := NewRouter(test.trustedProxies)
+			if err != nil {
+				t.Fatalf("NewRouter() error = %v", err)
+			}
+
+			var clientIP string
+			router.GET("/", func(ctx *gin.Context) { clientIP = ctx.ClientIP() })
+
+			// httptest requests come from 192.0.2.1
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set("X-Forwarded-For", "198.51.100.1")
+			router.ServeHTTP(httptest.NewRecorder(), request)
+
+			if clientIP != test.want {
+				t.Errorf("ClientIP() = %q, want %q", clientIP, test.want)
+			}
+		})
+	}
+}
@@ -1,18 +1,15 @@
 package helper
 
-import (
-	"github.com/go-playground/validator/v10"
-)
-
 type Response struct {
 	Meta Meta        `json:"meta"`
 	Data interface{} `json:"data"`
 }
 
 type Meta struct {
-	Message string `json:"message"`
-	Code    int    `json:"code"`
-	Status  string `json:"status"`
+	Message   string `json:"message"`
+	Code      int    `json:"code"`
+	Status    string `json:"status"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 func APIResponse(message string, code int, status string, data interface{}) Response {
@@ -30,12 +27,9 @@ func APIResponse(message string, code int, status string, data interface{}) Resp
 	return jsonResponse
 }
 
-func FormatValidationError(err error) []string {
-	var errors []string
-
-	for _, e := range err.(validator.ValidationErrors) {
-		errors = append(errors, e.Error())
-	}
+func APIErrorResponse(message string, code int, errorCode string, data interface{}) Response {
+	jsonResponse := APIResponse(message, code, "error", data)
+	jsonResponse.Meta.ErrorCode = errorCode
 
-	return errors
+	return jsonResponse
 }
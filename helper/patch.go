@@ -0,0 +1,51 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// NullFieldError reports a field set to null in a merge patch, which would
+// remove a field that cannot be removed.
+type NullFieldError struct {
+	Field string
+}
+
+func (e *NullFieldError) Error() string {
+	return e.Field + " cannot be null"
+}
+
+// BindMergePatch decodes a JSON Merge Patch (RFC 7396) into obj, whose fields
+// are pointers that stay nil when left out of the patch, and validates it.
+func BindMergePatch(body []byte, obj interface{}) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		return errors.New("merge patch must be a JSON object")
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil {
+		return err
+	}
+
+	var fields []string
+	for field := range members {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		if string(members[field]) == "null" {
+			return &NullFieldError{Field: field}
+		}
+	}
+
+	if err := json.Unmarshal(body, obj); err != nil {
+		return err
+	}
+
+	return binding.Validator.ValidateStruct(obj)
+}
@@ -0,0 +1,134 @@
+package helper
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type patchInput struct {
+	Name   *string `json:"name" binding:"omitempty,min=3"`
+	Amount *int    `json:"amount" binding:"omitempty,gt=0"`
+}
+
+func TestBindMergePatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantName   *string
+		wantAmount *int
+	}{
+		{"empty patch", `{}`, nil, nil},
+		{"one field", `{"name": "Solar Kiosk"}`, stringPointer("Solar Kiosk"), nil},
+		{"every field", `{"name": "Solar Kiosk", "amount": 100}`, stringPointer("Solar Kiosk"), intPointer(100)},
+		{"unknown field", `{"amount": 100, "unknown": "ignored"}`, nil, intPointer(100)},
+		{"leading whitespace", " \n{\"amount\": 100}", nil, intPointer(100)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var input patchInput
+
+			if err := BindMergePatch([]byte(test.body), &input); err != nil {
+				t.Fatalf("BindMergePatch() error = %v", err)
+			}
+
+			if !equalStringPointers(input.Name, test.wantName) {
+				t.Errorf("Name = %v, want %v", input.Name, test.wantName)
+			}
+
+			if !equalIntPointers(input.Amount, test.wantAmount) {
+				t.Errorf("Amount = %v, want %v", input.Amount, test.wantAmount)
+			}
+		})
+	}
+}
+
+func TestBindMergePatchRejectsInvalidPatches(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"empty body", ``},
+		{"array", `[{"name": "Solar Kiosk"}]`},
+		{"string", `"Solar Kiosk"`},
+		{"null", `null`},
+		{"malformed JSON", `{"name": }`},
+		{"wrong type", `{"amount": "100"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var input patchInput
+
+			if err := BindMergePatch([]byte(test.body), &input); err == nil {
+				t.Error("BindMergePatch() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestBindMergePatchRejectsNullFields(t *testing.T) {
+	var input patchInput
+
+	err := BindMergePatch([]byte(`{"name": null, "amount": null}`), &input)
+
+	var nullFieldError *NullFieldError
+	if !errors.As(err, &nullFieldError) {
+		t.Fatalf("BindMergePatch() error = %v, want a NullFieldError", err)
+	}
+
+	// The first field in alphabetical order, so the error is stable
+	if nullFieldError.Field != "amount" {
+		t.Errorf("Field = %q, want %q", nullFieldError.Field, "amount")
+	}
+}
+
+func TestBindMergePatchValidates(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{"too short", `{"name": "ab"}`, "Name"},
+		{"not positive", `{"amount": 0}`, "Amount"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var input patchInput
+
+			err := BindMergePatch([]byte(test.body), &input)
+
+			var validationErrors validator.ValidationErrors
+			if !errors.As(err, &validationErrors) || validationErrors[0].Field() != test.field {
+				t.Errorf("BindMergePatch() error = %v, want a validation error of %s", err, test.field)
+			}
+		})
+	}
+}
+
+func stringPointer(s string) *string {
+	return &s
+}
+
+func intPointer(i int) *int {
+	return &i
+}
+
+func equalStringPointers(got *string, want *string) bool {
+	if got == nil || want == nil {
+		return got == want
+	}
+
+	return *got == *want
+}
+
+func equalIntPointers(got *int, want *int) bool {
+	if got == nil || want == nil {
+		return got == want
+	}
+
+	return *got == *want
+}
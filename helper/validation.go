@@ -0,0 +1,172 @@
+package helper
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	idTranslations "github.com/go-playground/validator/v10/translations/id"
+)
+
+type ValidationError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+var universalTranslator = ut.New(en.New(), en.New(), id.New())
+
+// SetupValidator makes gin's validator report fields by their request tag
+// names and registers the English and Indonesian messages.
+func SetupValidator() error {
+	validate, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unsupported validator engine")
+	}
+
+	validate.RegisterTagNameFunc(fieldName)
+
+	enTranslator, _ := universalTranslator.GetTranslator("en")
+	if err := enTranslations.RegisterDefaultTranslations(validate, enTranslator); err != nil {
+		return err
+	}
+
+	if err := enTranslator.Add("type", "{0} must be a {1}", false); err != nil {
+		return err
+	}
+
+	if err := enTranslator.Add("not_null", "{0} cannot be null", false); err != nil {
+		return err
+	}
+
+	idTranslator, _ := universalTranslator.GetTranslator("id")
+	if err := idTranslations.RegisterDefaultTranslations(validate, idTranslator); err != nil {
+		return err
+	}
+
+	if err := idTranslator.Add("type", "{0} harus berupa {1}", false); err != nil {
+		return err
+	}
+
+	if err := idTranslator.Add("not_null", "{0} tidak boleh null", false); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+
+		if name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// Translator picks the translator matching an Accept-Language header,
+// falling back to English.
+func Translator(acceptLanguage string) ut.Translator {
+	translator, _ := universalTranslator.FindTranslator(parseAcceptLanguage(acceptLanguage)...)
+
+	return translator
+}
+
+func parseAcceptLanguage(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	var languages []language
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		if fields[0] == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+
+			if value, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+				quality = value
+			}
+		}
+
+		languages = append(languages, language{fields[0], quality})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+
+	var locales []string
+
+	for _, l := range languages {
+		tag := strings.ReplaceAll(l.tag, "-", "_")
+		locales = append(locales, tag, strings.SplitN(tag, "_", 2)[0])
+	}
+
+	return locales
+}
+
+func FormatValidationError(err error, translator ut.Translator) []ValidationError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		var errors []ValidationError
+
+		for _, e := range validationErrors {
+			errors = append(errors, ValidationError{
+				Field:   e.Field(),
+				Rule:    e.Tag(),
+				Message: e.Translate(translator),
+			})
+		}
+
+		return errors
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		message, _ := translator.T("type", typeError.Field, typeError.Type.String())
+
+		return []ValidationError{{
+			Field:   typeError.Field,
+			Rule:    "type",
+			Message: message,
+		}}
+	}
+
+	var nullFieldError *NullFieldError
+	if errors.As(err, &nullFieldError) {
+		message, _ := translator.T("not_null", nullFieldError.Field)
+
+		return []ValidationError{{
+			Field:   nullFieldError.Field,
+			Rule:    "not_null",
+			Message: message,
+		}}
+	}
+
+	return []ValidationError{{Message: err.Error()}}
+}
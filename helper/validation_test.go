@@ -0,0 +1,121 @@
+package helper
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+type registerInput struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+var (
+	setupOnce     sync.Once
+	setupErr      error
+	validRegister = registerInput{Name: "Alice", Email: "alice@example.com", Password: "correct horse"}
+)
+
+// setupValidator registers the translations once, registering them again
+// fails.
+func setupValidator(t *testing.T) {
+	t.Helper()
+
+	setupOnce.Do(func() { setupErr = SetupValidator() })
+	if setupErr != nil {
+		t.Fatalf("SetupValidator() error = %v", setupErr)
+	}
+}
+
+func TestFormatValidationError(t *testing.T) {
+	setupValidator(t)
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		modify         func(input *registerInput)
+		want           []ValidationError
+	}{
+		{
+			name:   "required field in English",
+			modify: func(input *registerInput) { input.Name = "" },
+			want:   []ValidationError{{Field: "name", Rule: "required", Message: "name is a required field"}},
+		},
+		{
+			name:           "required field in Indonesian",
+			acceptLanguage: "id-ID,id;q=0.9,en;q=0.8",
+			modify:         func(input *registerInput) { input.Name = "" },
+			want:           []ValidationError{{Field: "name", Rule: "required", Message: "name wajib diisi"}},
+		},
+		{
+			name:           "unsupported language falls back to English",
+			acceptLanguage: "fr-FR",
+			modify:         func(input *registerInput) { input.Email = "alice" },
+			want:           []ValidationError{{Field: "email", Rule: "email", Message: "email must be a valid email address"}},
+		},
+		{
+			name:           "preferred language by quality",
+			acceptLanguage: "en;q=0.5, id;q=0.8",
+			modify:         func(input *registerInput) { input.Email = "alice" },
+			want:           []ValidationError{{Field: "email", Rule: "email", Message: "email harus berupa alamat email yang valid"}},
+		},
+		{
+			name:   "several fields",
+			modify: func(input *registerInput) { input.Name = ""; input.Password = "short" },
+			want: []ValidationError{
+				{Field: "name", Rule: "required", Message: "name is a required field"},
+				{Field: "password", Rule: "min", Message: "password must be at least 8 characters in length"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := validRegister
+			test.modify(&input)
+
+			err := binding.Validator.ValidateStruct(&input)
+			if err == nil {
+				t.Fatal("ValidateStruct() error = nil, want an error")
+			}
+
+			got := FormatValidationError(err, Translator(test.acceptLanguage))
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("FormatValidationError() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFormatValidationErrorOfDecoding(t *testing.T) {
+	setupValidator(t)
+
+	var input registerInput
+	typeErr := json.Unmarshal([]byte(`{"name": 42}`), &input)
+
+	tests := []struct {
+		name           string
+		err            error
+		acceptLanguage string
+		want           ValidationError
+	}{
+		{"wrong type", typeErr, "en", ValidationError{Field: "name", Rule: "type", Message: "name must be a string"}},
+		{"wrong type in Indonesian", typeErr, "id", ValidationError{Field: "name", Rule: "type", Message: "name harus berupa string"}},
+		{"null field", &NullFieldError{Field: "name"}, "en", ValidationError{Field: "name", Rule: "not_null", Message: "name cannot be null"}},
+		{"null field in Indonesian", &NullFieldError{Field: "name"}, "id", ValidationError{Field: "name", Rule: "not_null", Message: "name tidak boleh null"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := FormatValidationError(test.err, Translator(test.acceptLanguage))
+			if len(got) != 1 || got[0] != test.want {
+				t.Errorf("FormatValidationError() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package mailer
+
+import (
+	"errors"
+	"log"
+	"sync"
+)
+
+var (
+	ErrQueueFull    = errors.New("mail queue is full")
+	ErrMailerClosed = errors.New("mailer is closed")
+)
+
+type asyncMailer struct {
+	mailer Mailer
+	queue  chan Message
+	wait   sync.WaitGroup
+	// mutex guards closed, so no message is queued while the queue closes
+	mutex  sync.RWMutex
+	closed bool
+}
+
+// NewAsyncMailer queues messages and delivers them with mailer in the
+// background, so callers never wait on the mail server. Delivery failures
+// are logged.
+func NewAsyncMailer(mailer Mailer, queueSize int, workers int) *asyncMailer {
+	m := &asyncMailer{
+		mailer: mailer,
+		queue:  make(chan Message, queueSize),
+	}
+
+	for i := 0; i < workers; i++ {
+		m.wait.Add(1)
+		go m.work()
+	}
+
+	return m
+}
+
+func (m *asyncMailer) Send(message Message) error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.closed {
+		return ErrMailerClosed
+	}
+
+	select {
+	case m.queue <- message:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting messages and waits until the queue is drained.
+// Messages sent afterwards fail with ErrMailerClosed.
+func (m *asyncMailer) Close() {
+	m.mutex.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.queue)
+	}
+	m.mutex.Unlock()
+
+	m.wait.Wait()
+}
+
+func (m *asyncMailer) work() {
+	defer m.wait.Done()
+
+	for message := range m.queue {
+		if err := m.mailer.Send(message); err != nil {
+			log.Printf("failed to send %q to %s: %v", message.Subject, message.To, err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestAsyncMailerDeliversEveryMessageBeforeClosing(t *testing.T) {
+	memory := NewMemoryMailer()
+	async := NewAsyncMailer(memory, 10, 2)
+
+	for i := 0; i < 10; i++ {
+		if err := async.Send(Message{To: fmt.Sprintf("backer-%d@example.com", i)}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	async.Close()
+
+	if got := len(memory.Messages()); got != 10 {
+		t.Errorf("delivered %d messages, want 10", got)
+	}
+}
+
+// blockingMailer holds every delivery until release is closed.
+type blockingMailer struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *blockingMailer) Send(message Message) error {
+	m.started <- struct{}{}
+	<-m.release
+
+	return nil
+}
+
+func TestAsyncMailerReportsFullQueue(t *testing.T) {
+	blocking := &blockingMailer{make(chan struct{}, 1), make(chan struct{})}
+	async := NewAsyncMailer(blocking, 1, 1)
+
+	// The worker holds the first message, the second fills the queue
+	if err := async.Send(Message{To: "first@example.com"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	<-blocking.started
+
+	if err := async.Send(Message{To: "second@example.com"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if err := async.Send(Message{To: "third@example.com"}); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Send() error = %v, want ErrQueueFull", err)
+	}
+
+	close(blocking.release)
+	async.Close()
+}
+
+// failingMailer fails every delivery.
+type failingMailer struct{}
+
+func (failingMailer) Send(message Message) error {
+	return errors.New("connection refused")
+}
+
+func TestAsyncMailerKeepsWorkingAfterFailures(t *testing.T) {
+	async := NewAsyncMailer(failingMailer{}, 2, 1)
+
+	for i := 0; i < 2; i++ {
+		if err := async.Send(Message{To: "backer@example.com"}); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	async.Close()
+}
+
+func TestAsyncMailerRejectsMessagesAfterClosing(t *testing.T) {
+	memory := NewMemoryMailer()
+	async := NewAsyncMailer(memory, 1, 1)
+
+	async.Close()
+
+	if err := async.Send(Message{To: "late@example.com"}); !errors.Is(err, ErrMailerClosed) {
+		t.Errorf("Send() error = %v, want ErrMailerClosed", err)
+	}
+
+	// Closing twice is harmless
+	async.Close()
+
+	if got := len(memory.Messages()); got != 0 {
+		t.Errorf("delivered %d messages, want 0", got)
+	}
+}
+
+func TestAsyncMailerClosesWhileSending(t *testing.T) {
+	async := NewAsyncMailer(NewMemoryMailer(), 100, 2)
+
+	var wait sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+
+			for j := 0; j < 100; j++ {
+				err := async.Send(Message{To: "backer@example.com"})
+				if err != nil && !errors.Is(err, ErrQueueFull) && !errors.Is(err, ErrMailerClosed) {
+					t.Errorf("Send() error = %v", err)
+				}
+			}
+		}()
+	}
+
+	async.Close()
+	wait.Wait()
+}
+
+func TestFileMailerWritesEML(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer := NewFileMailer(dir, "no-reply@backer.example")
+
+	if err := mailer.Send(Message{To: "ada@example.com", Subject: "Hello", Text: "plain body"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-ada@example.com.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("written files = %v, %v, want one .eml file", files, err)
+	}
+
+	body, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(body), "Subject: Hello") || !strings.Contains(string(body), "plain body") {
+		t.Errorf("file = %q, want the encoded message", body)
+	}
+}
@@ -0,0 +1,62 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer writes every message as an .eml file into dir instead of
+// sending it, for local development.
+func NewFileMailer(dir string, from string) *fileMailer {
+	return &fileMailer{dir, from}
+}
+
+func (m *fileMailer) Send(message Message) error {
+	body, err := message.Bytes(m.from)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405.000000000"), message.To)
+
+	return os.WriteFile(filepath.Join(m.dir, filepath.Base(name)), body, 0644)
+}
+
+type MemoryMailer struct {
+	mutex    sync.Mutex
+	messages []Message
+}
+
+// NewMemoryMailer keeps sent messages in memory, for tests.
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(message Message) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.messages = append(m.messages, message)
+
+	return nil
+}
+
+// Messages returns every message sent so far.
+func (m *MemoryMailer) Messages() []Message {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
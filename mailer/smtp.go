@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type smtpMailer struct {
+	config SMTPConfig
+}
+
+func NewSMTPMailer(config SMTPConfig) *smtpMailer {
+	return &smtpMailer{config}
+}
+
+// Send delivers message through the SMTP server, upgrading the connection
+// with STARTTLS when the server offers it.
+func (m *smtpMailer) Send(message Message) error {
+	body, err := message.Bytes(m.config.From)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+
+	address := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+
+	return smtp.SendMail(address, auth, m.config.From, []string{message.To}, body)
+}
@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmlTemplate "html/template"
+	textTemplate "text/template"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Names of the available templates
+const (
+	TemplateEmailVerification = "email_verification"
+	TemplatePasswordReset     = "password_reset"
+	TemplatePledgeReceipt     = "pledge_receipt"
+	TemplateCampaignUpdate    = "campaign_update"
+)
+
+// NewMessage renders the named template into a message to the given
+// address. Every template consists of templates/<name>.txt, which defines
+// "subject" and "text", and templates/<name>.html, which defines "html".
+func NewMessage(to string, name string, data interface{}) (Message, error) {
+	message := Message{To: to}
+
+	text, err := textTemplate.ParseFS(templates, "templates/"+name+".txt")
+	if err != nil {
+		return message, err
+	}
+
+	html, err := htmlTemplate.ParseFS(templates, "templates/layout.html", "templates/"+name+".html")
+	if err != nil {
+		return message, err
+	}
+
+	var buffer bytes.Buffer
+
+	if err := text.ExecuteTemplate(&buffer, "subject", data); err != nil {
+		return message, err
+	}
+	message.Subject = buffer.String()
+	buffer.Reset()
+
+	if err := text.ExecuteTemplate(&buffer, "text", data); err != nil {
+		return message, err
+	}
+	message.Text = buffer.String()
+	buffer.Reset()
+
+	if err := html.ExecuteTemplate(&buffer, "layout", data); err != nil {
+		return message, err
+	}
+	message.HTML = buffer.String()
+
+	return message, nil
+}
@@ -0,0 +1,107 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewMessageRendersEveryTemplate(t *testing.T) {
+	data := map[string]string{
+		"Name":         "Ada",
+		"URL":          "https://backer.example/link?token=abc",
+		"CampaignName": "Solar Kiosk",
+		"Amount":       "Rp 100.000",
+		"Code":         "TRX-1",
+		"Title":        "We shipped",
+		"Body":         "The first batch is out.",
+	}
+
+	tests := []struct {
+		template string
+		subject  string
+	}{
+		{TemplateEmailVerification, "Verify your email address"},
+		{TemplatePasswordReset, "Reset your password"},
+		{TemplatePledgeReceipt, "Thank you for backing Solar Kiosk"},
+		{TemplateCampaignUpdate, "Solar Kiosk: We shipped"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.template, func(t *testing.T) {
+			message, err := NewMessage("ada@example.com", test.template, data)
+			if err != nil {
+				t.Fatalf("NewMessage() error = %v", err)
+			}
+
+			if message.To != "ada@example.com" {
+				t.Errorf("To = %q, want %q", message.To, "ada@example.com")
+			}
+
+			if message.Subject != test.subject {
+				t.Errorf("Subject = %q, want %q", message.Subject, test.subject)
+			}
+
+			if !strings.Contains(message.Text, "Hi Ada,") {
+				t.Errorf("Text = %q, want it to greet the recipient", message.Text)
+			}
+
+			if !strings.Contains(message.HTML, "<!DOCTYPE html>") || !strings.Contains(message.HTML, "Ada") {
+				t.Errorf("HTML = %q, want the layout with the recipient", message.HTML)
+			}
+		})
+	}
+}
+
+func TestNewMessageEscapesHTML(t *testing.T) {
+	message, err := NewMessage("ada@example.com", TemplateCampaignUpdate, map[string]string{
+		"Name":         "Ada",
+		"CampaignName": "Solar Kiosk",
+		"Title":        "Update",
+		"Body":         "<script>alert(1)</script>",
+		"URL":          "https://backer.example/campaigns/1/updates/1",
+	})
+	if err != nil {
+		t.Fatalf("NewMessage() error = %v", err)
+	}
+
+	if strings.Contains(message.HTML, "<script>") {
+		t.Errorf("HTML = %q, want the body escaped", message.HTML)
+	}
+
+	if !strings.Contains(message.Text, "<script>alert(1)</script>") {
+		t.Errorf("Text = %q, want the body as is", message.Text)
+	}
+}
+
+func TestNewMessageRejectsUnknownTemplate(t *testing.T) {
+	if _, err := NewMessage("ada@example.com", "unknown", nil); err == nil {
+		t.Error("NewMessage() error = nil, want an error")
+	}
+}
+
+func TestMessageBytes(t *testing.T) {
+	message := Message{
+		To:      "ada@example.com",
+		Subject: "Grüße",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+	}
+
+	body, err := message.Bytes("Backer <no-reply@backer.example>")
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"From: Backer <no-reply@backer.example>\r\n",
+		"To: ada@example.com\r\n",
+		"Subject: =?UTF-8?q?Gr=C3=BC=C3=9Fe?=\r\n",
+		"Content-Type: multipart/alternative; boundary=",
+		"plain body",
+		"<p>html body</p>",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Bytes() = %q, want it to contain %q", body, want)
+		}
+	}
+}
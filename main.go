@@ -1,14 +1,34 @@
 package main
 
 import (
+	"backer/apikey"
+	"backer/apperror"
 	"backer/auth"
 	"backer/campaign"
+	"backer/campaignupdate"
+	"backer/comment"
+	"backer/config"
 	"backer/handler"
 	"backer/helper"
+	"backer/mailer"
+	"backer/oauth"
+	"backer/session"
+	"backer/storage"
+	"backer/throttle"
+	"backer/transaction"
+	"backer/upload"
 	"backer/user"
+	"context"
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
@@ -17,49 +37,329 @@ import (
 )
 
 func main() {
-	// refer https://github.com/go-sal-driver/mysql#dsn-data-source-name for details
-	dsn := "root:@tcp(127.0.0.1:3306)/backer?charset=utf8mb4&parseTime=true&loc=Local"
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	cfg := config.Load()
+
+	db, err := gorm.Open(mysql.Open(cfg.DatabaseDSN), &gorm.Config{})
 	if err != nil {
 		log.Fatal(err.Error())
 	}
 
-	userRepository := user.NewRepository(db)
-	userService := user.NewService(userRepository)
+	if err := helper.SetupValidator(); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	store := newStore(cfg.Storage)
+	urls := storage.NewURLBuilder(store, storage.URLConfig{
+		BaseURL:                  cfg.PublicBaseURL,
+		CDNURL:                   cfg.CDNBaseURL,
+		AvatarPlaceholder:        cfg.AvatarPlaceholder,
+		CampaignImagePlaceholder: cfg.CampaignImagePlaceholder,
+	})
+
 	authService := auth.NewService()
-	userHandler := handler.NewUserHandler(userService, authService)
+	userRepository := user.NewRepository(db)
+	baseMailer := newMailer(cfg.Mail)
+	mail := mailer.NewAsyncMailer(baseMailer, 100, 2)
+	defer mail.Close()
+
+	loginLimiter := throttle.NewLimiter(20, 15*time.Minute, 15*time.Minute)
+	userService := user.NewService(userRepository, authService, user.NewMailNotifier(mail, cfg.AppURL), newPasswordHasher(cfg), loginLimiter)
+	sessionRepository := session.NewRepository(db)
+	sessionService := session.NewService(sessionRepository)
+	sessionHandler := handler.NewSessionHandler(sessionService)
+
+	userHandler := handler.NewUserHandler(userService, sessionService, authService, store, urls)
+	oauthHandler := handler.NewOAuthHandler(newOAuthProviders(cfg), userService, sessionService, authService, urls)
+
+	apiKeyRepository := apikey.NewRepository(db)
+	apiKeyService := apikey.NewService(apiKeyRepository)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+
+	campaignRepository := campaign.NewRepository(db)
+	campaignService := campaign.NewService(campaignRepository)
+	campaignHandler := handler.NewCampaignHandler(campaignService, store, urls)
+
+	transactionRepository := transaction.NewRepository(db)
+	transactionService := transaction.NewService(transactionRepository)
 
-	router := gin.Default()
+	// Updates go out to every backer at once, which would overflow the mail
+	// queue, so they are sent one by one in the background instead
+	campaignUpdateRepository := campaignupdate.NewRepository(db)
+	campaignUpdateService := campaignupdate.NewService(campaignUpdateRepository, campaignService, transactionService, campaignupdate.NewMailNotifier(baseMailer, cfg.AppURL))
+	defer campaignUpdateService.Close()
+	campaignUpdateHandler := handler.NewCampaignUpdateHandler(campaignUpdateService, store, urls)
 
-	router.Static("images/", "./images")
+	commentRepository := comment.NewRepository(db)
+	commentService := comment.NewService(commentRepository, campaignService, cfg.RequireAdminTwoFactor)
+	commentHandler := handler.NewCommentHandler(commentService, urls)
+
+	accountHandler := handler.NewAccountHandler(userService, sessionService, apiKeyService, campaignService, transactionService, store, urls)
+
+	authenticate := authMiddleware(userService, sessionService, apiKeyService, authService)
+	authenticateIfPresent := optionalAuthMiddleware(authenticate)
+
+	router, err := handler.NewRouter(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	router.Static("/static", "./static")
+
+	if cfg.Storage.Driver == "local" {
+		for _, dir := range []string{upload.AvatarDir, upload.CampaignImageDir, upload.CampaignUpdateImageDir} {
+			router.Static(cfg.Storage.LocalBaseURL+"/"+dir, filepath.Join(cfg.Storage.LocalDir, dir))
+		}
+	}
 
 	api := router.Group("/api/v1")
 
 	api.POST("/users", userHandler.RegisterUser)
 	api.POST("/sessions", userHandler.Login)
+	api.POST("/sessions/2fa", userHandler.VerifyTwoFactor)
 	api.POST("/email_checkers", userHandler.CheckEmailAvailability)
-	api.POST("/avatars", authMiddleware(userService, authService), userHandler.UploadAvatar)
-
-	campaignRepository := campaign.NewRepository(db)
-	campaignService := campaign.NewService(campaignRepository)
-	campaignHandler := handler.NewCampaignHandler(campaignService)
+	api.GET("/oauth/:provider", oauthHandler.Authorize)
+	api.GET("/oauth/:provider/callback", oauthHandler.Callback)
+	api.POST("/avatars", authenticate, userHandler.UploadAvatar)
+	api.GET("/users/me", authenticate, userHandler.GetProfile)
+	api.PUT("/users/me", authenticate, userHandler.UpdateProfile)
+	api.DELETE("/users/me", authenticate, accountHandler.DeleteAccount)
+	api.GET("/users/me/export", authenticate, accountHandler.ExportAccount)
+	api.PUT("/users/me/password", authenticate, userHandler.ChangePassword)
+	api.POST("/users/me/2fa", authenticate, userHandler.SetUpTwoFactor)
+	api.POST("/users/me/2fa/confirmation", authenticate, userHandler.EnableTwoFactor)
+	api.DELETE("/users/me/2fa", authenticate, userHandler.DisableTwoFactor)
+	api.GET("/users/me/sessions", authenticate, sessionHandler.GetSessions)
+	api.DELETE("/users/me/sessions", authenticate, sessionHandler.RevokeSessions)
+	api.DELETE("/users/me/sessions/:id", authenticate, sessionHandler.RevokeSession)
+	api.GET("/users/me/api-keys", authenticate, apiKeyHandler.GetAPIKeys)
+	api.POST("/users/me/api-keys", authenticate, apiKeyHandler.CreateAPIKey)
+	api.DELETE("/users/me/api-keys/:id", authenticate, apiKeyHandler.RevokeAPIKey)
+	api.POST("/email-verifications", userHandler.ConfirmEmail)
+	api.POST("/email-verifications/resend", authenticate, userHandler.ResendEmailVerification)
+	api.POST("/password-reset-requests", userHandler.RequestPasswordReset)
+	api.POST("/password-resets", userHandler.ResetPassword)
+	api.POST("/admin/users/:id/unlock", authenticate, adminMiddleware(cfg.RequireAdminTwoFactor), userHandler.UnlockUser)
 
 	api.GET("/campaigns", campaignHandler.GetCampaigns)
 	api.GET("/campaigns/:id", campaignHandler.GetCampaign)
-	api.POST("/campaigns", authMiddleware(userService, authService), campaignHandler.CreateCampaign)
-	api.PUT("/campaigns/:id", authMiddleware(userService, authService), campaignHandler.UpdateCampaign)
-	api.POST("/campaign-images", authMiddleware(userService, authService), campaignHandler.UploadCampaignImage)
+	api.GET("/campaigns/:id/revisions", campaignHandler.GetCampaignRevisions)
+	api.POST("/campaigns", authenticate, verifiedEmailMiddleware(cfg.RequireEmailVerification), campaignHandler.CreateCampaign)
+	api.PUT("/campaigns/:id", authenticate, campaignHandler.UpdateCampaign)
+	api.PATCH("/campaigns/:id", authenticate, campaignHandler.PatchCampaign)
+	api.DELETE("/campaigns/:id", authenticate, campaignHandler.DeleteCampaign)
+	api.POST("/campaigns/:id/archive", authenticate, campaignHandler.ArchiveCampaign)
+	api.DELETE("/campaigns/:id/archive", authenticate, campaignHandler.UnarchiveCampaign)
+	api.PUT("/campaigns/:id/images/order", authenticate, campaignHandler.ReorderCampaignImages)
+	api.POST("/campaigns/:id/faqs", authenticate, campaignHandler.CreateCampaignFAQ)
+	api.PUT("/campaigns/:id/faqs/order", authenticate, campaignHandler.ReorderCampaignFAQs)
+	api.PUT("/campaigns/:id/faqs/:faq_id", authenticate, campaignHandler.UpdateCampaignFAQ)
+	api.DELETE("/campaigns/:id/faqs/:faq_id", authenticate, campaignHandler.DeleteCampaignFAQ)
+	api.POST("/campaign-images", authenticate, campaignHandler.UploadCampaignImage)
+	api.DELETE("/campaign-images/:id", authenticate, campaignHandler.DeleteCampaignImage)
+	api.PUT("/campaign-images/:id/primary", authenticate, campaignHandler.SetPrimaryCampaignImage)
 
-	router.Run()
+	api.GET("/campaigns/:id/updates", authenticateIfPresent, campaignUpdateHandler.GetCampaignUpdates)
+	api.GET("/campaigns/:id/updates/:update_id", authenticateIfPresent, campaignUpdateHandler.GetCampaignUpdate)
+	api.POST("/campaigns/:id/updates", authenticate, campaignUpdateHandler.CreateCampaignUpdate)
+	api.PUT("/campaigns/:id/updates/:update_id", authenticate, campaignUpdateHandler.UpdateCampaignUpdate)
+	api.DELETE("/campaigns/:id/updates/:update_id", authenticate, campaignUpdateHandler.DeleteCampaignUpdate)
+	api.POST("/campaigns/:id/updates/:update_id/images", authenticate, campaignUpdateHandler.UploadCampaignUpdateImage)
+	api.DELETE("/campaigns/:id/updates/:update_id/images/:image_id", authenticate, campaignUpdateHandler.DeleteCampaignUpdateImage)
+
+	api.GET("/campaigns/:id/comments", authenticateIfPresent, commentHandler.GetComments)
+	api.POST("/campaigns/:id/comments", authenticate, verifiedEmailMiddleware(cfg.RequireEmailVerification), commentHandler.CreateComment)
+	api.PUT("/campaigns/:id/comments/:comment_id", authenticate, commentHandler.UpdateComment)
+	api.DELETE("/campaigns/:id/comments/:comment_id", authenticate, commentHandler.DeleteComment)
+	api.POST("/campaigns/:id/comments/:comment_id/hide", authenticate, commentHandler.HideComment)
+	api.DELETE("/campaigns/:id/comments/:comment_id/hide", authenticate, commentHandler.UnhideComment)
+	api.POST("/campaigns/:id/comments/:comment_id/pin", authenticate, commentHandler.PinComment)
+	api.DELETE("/campaigns/:id/comments/:comment_id/pin", authenticate, commentHandler.UnpinComment)
+
+	serve(router)
+}
+
+// serve runs the API until the process is told to stop, then lets running
+// requests finish so the deferred cleanups in main, such as draining the
+// mail queue, get to run.
+func serve(router *gin.Engine) {
+	// The same address router.Run listens on
+	address := ":8080"
+	if port := os.Getenv("PORT"); port != "" {
+		address = ":" + port
+	}
+
+	server := &http.Server{Addr: address, Handler: router}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("failed to shut down gracefully: %v", err)
+	}
+}
+
+func newOAuthProviders(cfg config.Config) []oauth.Provider {
+	var providers []oauth.Provider
+
+	redirectURL := func(name string) string {
+		return strings.TrimSuffix(cfg.PublicBaseURL, "/") + "/api/v1/oauth/" + name + "/callback"
+	}
+
+	if cfg.OAuth.GoogleClientID != "" {
+		providers = append(providers, oauth.NewOIDCProvider(oauth.OIDCConfig{
+			Name:   "google",
+			Issuer: cfg.OAuth.GoogleIssuer,
+			Config: oauth.Config{
+				ClientID:     cfg.OAuth.GoogleClientID,
+				ClientSecret: cfg.OAuth.GoogleClientSecret,
+				RedirectURL:  redirectURL("google"),
+			},
+		}))
+	}
+
+	if cfg.OAuth.GitHubClientID != "" {
+		providers = append(providers, oauth.NewGitHubProvider(oauth.GitHubConfig{
+			Config: oauth.Config{
+				ClientID:     cfg.OAuth.GitHubClientID,
+				ClientSecret: cfg.OAuth.GitHubClientSecret,
+				RedirectURL:  redirectURL("github"),
+			},
+		}))
+	}
+
+	return providers
+}
+
+func newStore(cfg config.Storage) storage.Store {
+	switch cfg.Driver {
+	case "local":
+		return storage.NewLocalStore(cfg.LocalDir, cfg.LocalBaseURL)
+	case "s3":
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PublicURL: cfg.S3PublicURL,
+		})
+	default:
+		log.Fatalf("unknown storage driver %q", cfg.Driver)
+		return nil
+	}
 }
 
-func authMiddleware(userService user.Service, authService auth.Service) gin.HandlerFunc {
+func newPasswordHasher(cfg config.Config) user.PasswordHasher {
+	switch cfg.PasswordHasher {
+	case "bcrypt":
+		return user.NewBcryptHasher(cfg.BcryptCost)
+	case "argon2id":
+		return user.NewArgon2idHasher(user.DefaultArgon2idParams)
+	default:
+		log.Fatalf("unknown password hasher %q", cfg.PasswordHasher)
+		return nil
+	}
+}
+
+func newMailer(cfg config.Mail) mailer.Mailer {
+	switch cfg.Driver {
+	case "file":
+		return mailer.NewFileMailer(cfg.CaptureDir, cfg.From)
+	case "smtp":
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		})
+	default:
+		log.Fatalf("unknown mail driver %q", cfg.Driver)
+		return nil
+	}
+}
+
+// apiKeyScopes lists the only endpoints API keys can be used with, and the
+// scope each of them requires. Everything else, such as managing passwords,
+// sessions or the keys themselves, needs a logged in user.
+var apiKeyScopes = map[string]string{
+	"GET /api/v1/users/me":                                             apikey.ScopeProfileRead,
+	"PUT /api/v1/users/me":                                             apikey.ScopeProfileWrite,
+	"POST /api/v1/avatars":                                             apikey.ScopeProfileWrite,
+	"POST /api/v1/campaigns":                                           apikey.ScopeCampaignsWrite,
+	"PUT /api/v1/campaigns/:id":                                        apikey.ScopeCampaignsWrite,
+	"PATCH /api/v1/campaigns/:id":                                      apikey.ScopeCampaignsWrite,
+	"DELETE /api/v1/campaigns/:id":                                     apikey.ScopeCampaignsWrite,
+	"POST /api/v1/campaigns/:id/archive":                               apikey.ScopeCampaignsWrite,
+	"DELETE /api/v1/campaigns/:id/archive":                             apikey.ScopeCampaignsWrite,
+	"PUT /api/v1/campaigns/:id/images/order":                           apikey.ScopeCampaignsWrite,
+	"POST /api/v1/campaigns/:id/faqs":                                  apikey.ScopeCampaignsWrite,
+	"PUT /api/v1/campaigns/:id/faqs/order":                             apikey.ScopeCampaignsWrite,
+	"PUT /api/v1/campaigns/:id/faqs/:faq_id":                           apikey.ScopeCampaignsWrite,
+	"DELETE /api/v1/campaigns/:id/faqs/:faq_id":                        apikey.ScopeCampaignsWrite,
+	"POST /api/v1/campaign-images":                                     apikey.ScopeCampaignsWrite,
+	"DELETE /api/v1/campaign-images/:id":                               apikey.ScopeCampaignsWrite,
+	"PUT /api/v1/campaign-images/:id/primary":                          apikey.ScopeCampaignsWrite,
+	"POST /api/v1/campaigns/:id/updates":                               apikey.ScopeCampaignsWrite,
+	"PUT /api/v1/campaigns/:id/updates/:update_id":                     apikey.ScopeCampaignsWrite,
+	"DELETE /api/v1/campaigns/:id/updates/:update_id":                  apikey.ScopeCampaignsWrite,
+	"POST /api/v1/campaigns/:id/updates/:update_id/images":             apikey.ScopeCampaignsWrite,
+	"DELETE /api/v1/campaigns/:id/updates/:update_id/images/:image_id": apikey.ScopeCampaignsWrite,
+}
+
+func authMiddleware(userService user.Service, sessionService session.Service, apiKeyService apikey.Service, authService auth.Service) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		unauthorized := func() {
+			ctx.Error(apperror.Unauthorized("Unauthorized")).SetMeta("Unauthorized")
+			ctx.Abort()
+		}
+
+		// Integrations authenticate with an API key instead of a token
+		if key := ctx.GetHeader("X-API-Key"); key != "" {
+			scope, ok := apiKeyScopes[ctx.Request.Method+" "+ctx.FullPath()]
+			if !ok {
+				ctx.Error(apikey.ErrNotAllowed)
+				ctx.Abort()
+				return
+			}
+
+			currentAPIKey, err := apiKeyService.Authenticate(key, ctx.ClientIP())
+			if err != nil {
+				unauthorized()
+				return
+			}
+
+			if !currentAPIKey.HasScope(scope) {
+				ctx.Error(apikey.ErrScopeNotGranted)
+				ctx.Abort()
+				return
+			}
+
+			user, err := userService.GetUserByID(currentAPIKey.UserID)
+			if err != nil {
+				unauthorized()
+				return
+			}
+
+			ctx.Set("currentUser", user)
+			ctx.Set("currentAPIKey", currentAPIKey)
+			return
+		}
+
 		authHeader := ctx.GetHeader("Authorization")
 
 		if !strings.Contains(authHeader, "Bearer") {
-			response := helper.APIResponse("Unauthorized", http.StatusUnauthorized, "error", nil)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response)
+			unauthorized()
 			return
 		}
 
@@ -72,27 +372,97 @@ func authMiddleware(userService user.Service, authService auth.Service) gin.Hand
 
 		token, err := authService.ValidateToken(tokenString)
 		if err != nil {
-			response := helper.APIResponse("Unauthorized", http.StatusUnauthorized, "error", nil)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response)
+			unauthorized()
 			return
 		}
 
 		claim, ok := token.Claims.(jwt.MapClaims)
 		if !ok || !token.Valid {
-			response := helper.APIResponse("Unauthorized", http.StatusUnauthorized, "error", nil)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response)
+			unauthorized()
 			return
 		}
 
-		userID := int(claim["user_id"].(float64))
+		userID, ok := claim["user_id"].(float64)
+		if !ok {
+			unauthorized()
+			return
+		}
+
+		user, err := userService.GetUserByID(int(userID))
+		if err != nil {
+			unauthorized()
+			return
+		}
+
+		// Changing the password signs out every session issued before
+		issuedAt, _ := claim["iat"].(float64)
+		if user.PasswordChangedAt != nil && int64(math.Round(issuedAt*1000)) < user.PasswordChangedAt.UnixMilli() {
+			unauthorized()
+			return
+		}
+
+		// Tokens stay valid only as long as their session is not revoked
+		sessionID, ok := claim["sid"].(float64)
+		if !ok {
+			unauthorized()
+			return
+		}
 
-		user, err := userService.GetUserByID(userID)
+		currentSession, err := sessionService.CheckSession(int(sessionID), user.ID, ctx.ClientIP())
 		if err != nil {
-			response := helper.APIResponse("Unauthorized", http.StatusUnauthorized, "error", nil)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response)
+			unauthorized()
 			return
 		}
 
 		ctx.Set("currentUser", user)
+		ctx.Set("currentSession", currentSession)
+	}
+}
+
+// optionalAuthMiddleware runs authenticate only when the request carries
+// credentials, letting anonymous requests through without a current user.
+func optionalAuthMiddleware(authenticate gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetHeader("Authorization") == "" && ctx.GetHeader("X-API-Key") == "" {
+			return
+		}
+
+		authenticate(ctx)
+	}
+}
+
+// verifiedEmailMiddleware rejects users whose email is not verified yet when
+// verification is required. It must run after authMiddleware.
+func verifiedEmailMiddleware(required bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !required {
+			return
+		}
+
+		currentUser := ctx.MustGet("currentUser").(user.User)
+
+		if currentUser.EmailVerifiedAt == nil {
+			ctx.Error(user.ErrEmailNotVerified).SetMeta("Email verification required")
+			ctx.Abort()
+		}
+	}
+}
+
+// adminMiddleware only lets administrators through, optionally only those
+// with two-factor authentication enabled. It must run after authMiddleware.
+func adminMiddleware(requireTwoFactor bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		currentUser := ctx.MustGet("currentUser").(user.User)
+
+		if currentUser.Role != "admin" {
+			ctx.Error(apperror.Forbidden("Forbidden")).SetMeta("Forbidden")
+			ctx.Abort()
+			return
+		}
+
+		if requireTwoFactor && currentUser.TwoFactorEnabledAt == nil {
+			ctx.Error(user.ErrTwoFactorRequired).SetMeta("Two-factor authentication required")
+			ctx.Abort()
+		}
 	}
 }
@@ -0,0 +1,102 @@
+package oauth
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type GitHubConfig struct {
+	Config
+	// BaseURL and APIURL default to github.com, point them elsewhere for
+	// GitHub Enterprise or a mock server
+	BaseURL string
+	APIURL  string
+}
+
+type gitHubUser struct {
+	ID    int64  `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+type gitHubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// gitHubProvider logs in with GitHub, which speaks plain OAuth 2.0 rather
+// than OpenID Connect, so the identity is read from its REST API.
+type gitHubProvider struct {
+	config GitHubConfig
+	client *http.Client
+}
+
+func NewGitHubProvider(config GitHubConfig) *gitHubProvider {
+	if config.BaseURL == "" {
+		config.BaseURL = "https://github.com"
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	if config.APIURL == "" {
+		config.APIURL = "https://api.github.com"
+	}
+	config.APIURL = strings.TrimSuffix(config.APIURL, "/")
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"read:user", "user:email"}
+	}
+
+	return &gitHubProvider{config, newClient()}
+}
+
+func (p *gitHubProvider) Name() string {
+	return "github"
+}
+
+func (p *gitHubProvider) AuthCodeURL(state string) (string, error) {
+	return authCodeURL(p.config.BaseURL+"/login/oauth/authorize", p.config.Config, state), nil
+}
+
+func (p *gitHubProvider) Exchange(code string) (Identity, error) {
+	token, err := exchangeCode(p.client, p.config.BaseURL+"/login/oauth/access_token", p.config.Config, code)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	var user gitHubUser
+	if err := getJSON(p.client, p.config.APIURL+"/user", token.AccessToken, &user); err != nil {
+		return Identity{}, err
+	}
+
+	if user.ID == 0 {
+		return Identity{}, fmt.Errorf("github: user has no id")
+	}
+
+	// The profile only shows the public email, which may be unverified
+	var emails []gitHubEmail
+	if err := getJSON(p.client, p.config.APIURL+"/user/emails", token.AccessToken, &emails); err != nil {
+		return Identity{}, err
+	}
+
+	identity := Identity{
+		Provider: p.Name(),
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Name:     user.Name,
+	}
+
+	if identity.Name == "" {
+		identity.Name = user.Login
+	}
+
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+		}
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,206 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+type OIDCConfig struct {
+	Config
+	// Name identifies the provider in URLs and linked identities
+	Name string
+	// Issuer is the issuer identifier, e.g. "https://accounts.google.com".
+	// Endpoints are discovered from its well-known configuration, so any
+	// compliant issuer works, including a local mock one.
+	Issuer string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+type idTokenClaims struct {
+	jwt.StandardClaims
+	Audience      audience    `json:"aud"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	Name          string      `json:"name"`
+}
+
+type oidcProvider struct {
+	config OIDCConfig
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *discovery
+}
+
+func NewOIDCProvider(config OIDCConfig) *oidcProvider {
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &oidcProvider{config: config, client: newClient()}
+}
+
+func (p *oidcProvider) Name() string {
+	return p.config.Name
+}
+
+func (p *oidcProvider) AuthCodeURL(state string) (string, error) {
+	discovery, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	return authCodeURL(discovery.AuthorizationEndpoint, p.config.Config, state), nil
+}
+
+func (p *oidcProvider) Exchange(code string) (Identity, error) {
+	discovery, err := p.discover()
+	if err != nil {
+		return Identity{}, err
+	}
+
+	token, err := exchangeCode(p.client, discovery.TokenEndpoint, p.config.Config, code)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if token.IDToken == "" {
+		return Identity{}, fmt.Errorf("oidc: token response has no id token")
+	}
+
+	// The ID token comes straight from the token endpoint over TLS, which
+	// lets the client skip verifying its signature (OpenID Connect Core
+	// 3.1.3.7). The claims still have to be meant for this client.
+	var claims idTokenClaims
+	if _, _, err := new(jwt.Parser).ParseUnverified(token.IDToken, &claims); err != nil {
+		return Identity{}, err
+	}
+
+	if claims.Issuer != discovery.Issuer {
+		return Identity{}, fmt.Errorf("oidc: id token issued by %q, expected %q", claims.Issuer, discovery.Issuer)
+	}
+
+	if !claims.Audience.contains(p.config.ClientID) {
+		return Identity{}, fmt.Errorf("oidc: id token is not issued for this client")
+	}
+
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return Identity{}, fmt.Errorf("oidc: id token has expired")
+	}
+
+	if claims.Subject == "" {
+		return Identity{}, fmt.Errorf("oidc: id token has no subject")
+	}
+
+	identity := Identity{
+		Provider:      p.config.Name,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: isTrue(claims.EmailVerified),
+		Name:          claims.Name,
+	}
+
+	// Issuers may leave the profile out of the ID token
+	if identity.Email == "" && discovery.UserinfoEndpoint != "" {
+		var userinfo idTokenClaims
+		if err := getJSON(p.client, discovery.UserinfoEndpoint, token.AccessToken, &userinfo); err != nil {
+			return Identity{}, err
+		}
+
+		if userinfo.Subject != identity.Subject {
+			return Identity{}, fmt.Errorf("oidc: userinfo subject does not match the id token")
+		}
+
+		identity.Email = userinfo.Email
+		identity.EmailVerified = isTrue(userinfo.EmailVerified)
+		if identity.Name == "" {
+			identity.Name = userinfo.Name
+		}
+	}
+
+	return identity, nil
+}
+
+// discover fetches the issuer's configuration once it is first needed, so an
+// unreachable issuer does not keep the server from starting.
+func (p *oidcProvider) discover() (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d discovery
+	if err := getJSON(p.client, p.config.Issuer+"/.well-known/openid-configuration", "", &d); err != nil {
+		return nil, err
+	}
+
+	if d.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("oidc: discovered issuer %q, expected %q", d.Issuer, p.config.Issuer)
+	}
+
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" {
+		return nil, fmt.Errorf("oidc: issuer %q has no authorization or token endpoint", d.Issuer)
+	}
+
+	p.discovery = &d
+
+	return p.discovery, nil
+}
+
+// audience is the "aud" claim, either a single string or a list of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*a = list
+
+	return nil
+}
+
+func (a audience) contains(clientID string) bool {
+	for _, aud := range a {
+		if aud == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTrue reads boolean claims some issuers send as strings.
+func isTrue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
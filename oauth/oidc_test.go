@@ -0,0 +1,224 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	testClientID     = "backer-client"
+	testClientSecret = "backer-secret"
+	testCode         = "authorization-code"
+)
+
+// mockIssuer is a local OpenID Connect issuer which answers the code
+// exchange with the ID token built by claims.
+type mockIssuer struct {
+	server   *httptest.Server
+	claims   func(issuer string) jwt.MapClaims
+	userinfo map[string]interface{}
+}
+
+func newMockIssuer(t *testing.T, claims func(issuer string) jwt.MapClaims) *mockIssuer {
+	t.Helper()
+
+	issuer := &mockIssuer{claims: claims}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 issuer.server.URL,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"userinfo_endpoint":      issuer.server.URL + "/userinfo",
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("code") != testCode || r.PostFormValue("client_id") != testClientID || r.PostFormValue("client_secret") != testClientSecret {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		idToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, issuer.claims(issuer.server.URL)).SignedString([]byte("issuer key"))
+		if err != nil {
+			t.Errorf("failed to sign id token: %v", err)
+		}
+
+		writeJSON(w, map[string]string{"access_token": "access-token", "token_type": "Bearer", "id_token": idToken})
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		writeJSON(w, issuer.userinfo)
+	})
+
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+
+	return issuer
+}
+
+func (i *mockIssuer) provider() *oidcProvider {
+	return NewOIDCProvider(OIDCConfig{
+		Config: Config{
+			ClientID:     testClientID,
+			ClientSecret: testClientSecret,
+			RedirectURL:  "http://localhost/api/v1/oauth/mock/callback",
+		},
+		Name:   "mock",
+		Issuer: i.server.URL,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func validClaims(issuer string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            issuer,
+		"aud":            testClientID,
+		"sub":            "subject-1",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"email":          "backer@example.com",
+		"email_verified": true,
+		"name":           "Backer",
+	}
+}
+
+func TestOIDCProviderAuthCodeURL(t *testing.T) {
+	issuer := newMockIssuer(t, validClaims)
+
+	authCodeURL, err := issuer.provider().AuthCodeURL("some-state")
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	for _, want := range []string{issuer.server.URL + "/authorize?", "client_id=" + testClientID, "state=some-state", "scope=openid+email+profile"} {
+		if !strings.Contains(authCodeURL, want) {
+			t.Errorf("AuthCodeURL() = %q, want it to contain %q", authCodeURL, want)
+		}
+	}
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	issuer := newMockIssuer(t, validClaims)
+
+	identity, err := issuer.provider().Exchange(testCode)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	want := Identity{
+		Provider:      "mock",
+		Subject:       "subject-1",
+		Email:         "backer@example.com",
+		EmailVerified: true,
+		Name:          "Backer",
+	}
+	if identity != want {
+		t.Errorf("Exchange() = %+v, want %+v", identity, want)
+	}
+}
+
+func TestOIDCProviderExchangeFallsBackToUserinfo(t *testing.T) {
+	issuer := newMockIssuer(t, func(issuer string) jwt.MapClaims {
+		claims := validClaims(issuer)
+		delete(claims, "email")
+		delete(claims, "email_verified")
+		delete(claims, "name")
+
+		return claims
+	})
+	issuer.userinfo = map[string]interface{}{
+		"sub":            "subject-1",
+		"email":          "userinfo@example.com",
+		"email_verified": "true",
+		"name":           "Userinfo",
+	}
+
+	identity, err := issuer.provider().Exchange(testCode)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	if identity.Email != "userinfo@example.com" || !identity.EmailVerified || identity.Name != "Userinfo" {
+		t.Errorf("Exchange() = %+v, want the profile from userinfo", identity)
+	}
+}
+
+func TestOIDCProviderExchangeRejectsInvalidIDTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(claims jwt.MapClaims)
+	}{
+		{
+			name:   "wrong issuer",
+			modify: func(claims jwt.MapClaims) { claims["iss"] = "https://issuer.invalid" },
+		},
+		{
+			name:   "wrong audience",
+			modify: func(claims jwt.MapClaims) { claims["aud"] = []string{"another-client"} },
+		},
+		{
+			name:   "expired",
+			modify: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+		},
+		{
+			name:   "no expiry",
+			modify: func(claims jwt.MapClaims) { delete(claims, "exp") },
+		},
+		{
+			name:   "missing subject",
+			modify: func(claims jwt.MapClaims) { delete(claims, "sub") },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issuer := newMockIssuer(t, func(issuer string) jwt.MapClaims {
+				claims := validClaims(issuer)
+				test.modify(claims)
+
+				return claims
+			})
+
+			if identity, err := issuer.provider().Exchange(testCode); err == nil {
+				t.Errorf("Exchange() = %+v, want an error", identity)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderExchangeRejectsUserinfoOfAnotherSubject(t *testing.T) {
+	issuer := newMockIssuer(t, func(issuer string) jwt.MapClaims {
+		claims := validClaims(issuer)
+		delete(claims, "email")
+
+		return claims
+	})
+	issuer.userinfo = map[string]interface{}{"sub": "subject-2", "email": "someone@example.com"}
+
+	if identity, err := issuer.provider().Exchange(testCode); err == nil {
+		t.Errorf("Exchange() = %+v, want an error", identity)
+	}
+}
+
+func TestOIDCProviderExchangeRejectsInvalidCode(t *testing.T) {
+	issuer := newMockIssuer(t, validClaims)
+
+	if identity, err := issuer.provider().Exchange("wrong-code"); err == nil {
+		t.Errorf("Exchange() = %+v, want an error", identity)
+	}
+}
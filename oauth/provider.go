@@ -0,0 +1,152 @@
+package oauth
+
+import (
+	"backer/apperror"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrProviderNotFound = apperror.NotFound("Login provider not found")
+	ErrLoginFailed      = apperror.Unauthorized("Failed to log in with the provider")
+)
+
+// Identity is the account the user has at a provider.
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider logs users in through an external authorization server using
+// the OAuth 2.0 authorization code flow.
+type Provider interface {
+	Name() string
+	// AuthCodeURL is where the user is sent to grant access, the provider
+	// redirects back with the state and an authorization code.
+	AuthCodeURL(state string) (string, error)
+	// Exchange redeems the authorization code for the user's identity.
+	Exchange(code string) (Identity, error)
+}
+
+type ProviderInput struct {
+	Provider string `uri:"provider" binding:"required"`
+}
+
+// CallbackInput is what the provider redirects back with, either the code
+// and state or an error when the user denied access.
+type CallbackInput struct {
+	Code             string `form:"code"`
+	State            string `form:"state"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+}
+
+// Config is the client registration at a provider.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func newClient() *http.Client {
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+func authCodeURL(endpoint string, config Config, state string) string {
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {config.ClientID},
+		"redirect_uri":  {config.RedirectURL},
+		"scope":         {strings.Join(config.Scopes, " ")},
+		"state":         {state},
+	}
+
+	separator := "?"
+	if strings.Contains(endpoint, "?") {
+		separator = "&"
+	}
+
+	return endpoint + separator + query.Encode()
+}
+
+func exchangeCode(client *http.Client, endpoint string, config Config, code string) (tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {config.RedirectURL},
+		"client_id":     {config.ClientID},
+		"client_secret": {config.ClientSecret},
+	}
+
+	request, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return tokenResponse{}, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+
+	var token tokenResponse
+	if err := doJSON(client, request, &token); err != nil {
+		return token, err
+	}
+
+	// Some providers, GitHub included, report errors with a 200 status
+	if token.Error != "" {
+		return token, fmt.Errorf("oauth: %s: %s", token.Error, token.ErrorDescription)
+	}
+
+	if token.AccessToken == "" {
+		return token, fmt.Errorf("oauth: token response has no access token")
+	}
+
+	return token, nil
+}
+
+func getJSON(client *http.Client, endpoint string, accessToken string, v interface{}) error {
+	request, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+
+	if accessToken != "" {
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	return doJSON(client, request, v)
+}
+
+func doJSON(client *http.Client, request *http.Request, v interface{}) error {
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("oauth: unexpected status %s from %s: %.200s", response.Status, request.URL, body)
+	}
+
+	return json.Unmarshal(body, v)
+}
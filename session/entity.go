@@ -0,0 +1,15 @@
+package session
+
+import "time"
+
+// Session is a login on one device. Its ID is carried in the token, so
+// deleting the session revokes the token.
+type Session struct {
+	ID         int
+	UserID     int
+	UserAgent  string
+	IPAddress  string
+	LastSeenAt time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
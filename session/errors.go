@@ -0,0 +1,8 @@
+package session
+
+import "backer/apperror"
+
+var (
+	ErrSessionNotFound = apperror.NotFound("Session not found")
+	ErrSessionExpired  = apperror.Unauthorized("Session has expired")
+)
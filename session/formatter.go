@@ -0,0 +1,90 @@
+package session
+
+import (
+	"strings"
+	"time"
+)
+
+type SessionFormatter struct {
+	ID         int       `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	IsCurrent  bool      `json:"is_current"`
+}
+
+func FormatSession(session Session, currentSessionID int) SessionFormatter {
+	formatter := SessionFormatter{
+		ID:         session.ID,
+		Device:     describeDevice(session.UserAgent),
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		LastSeenAt: session.LastSeenAt,
+		CreatedAt:  session.CreatedAt,
+		IsCurrent:  session.ID == currentSessionID,
+	}
+
+	return formatter
+}
+
+func FormatSessions(sessions []Session, currentSessionID int) []SessionFormatter {
+	formatters := []SessionFormatter{}
+
+	for _, session := range sessions {
+		formatters = append(formatters, FormatSession(session, currentSessionID))
+	}
+
+	return formatters
+}
+
+// describeDevice turns a user agent into a short label such as "Chrome on
+// Windows". Order matters, e.g. Edge and Chrome both claim to be Safari.
+func describeDevice(userAgent string) string {
+	browsers := []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+
+	systems := []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+
+	var browser, system string
+
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	for _, s := range systems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	case userAgent != "":
+		return userAgent
+	default:
+		return "Unknown device"
+	}
+}
@@ -0,0 +1,14 @@
+package session
+
+import "backer/user"
+
+type CreateSessionInput struct {
+	UserID    int
+	UserAgent string
+	IPAddress string
+}
+
+type GetSessionInput struct {
+	ID   int `uri:"id" binding:"required"`
+	User user.User
+}
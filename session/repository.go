@@ -0,0 +1,79 @@
+package session
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Save(session Session) (Session, error)
+	FindByID(ID int) (Session, error)
+	FindByUserID(userID int) ([]Session, error)
+	Update(session Session) (Session, error)
+	Delete(session Session) error
+	DeleteByUserID(userID int, exceptID int) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) Save(session Session) (Session, error) {
+	if err := r.db.Create(&session).Error; err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+func (r *repository) FindByID(ID int) (Session, error) {
+	var session Session
+
+	err := r.db.Where("id = ?", ID).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return session, ErrSessionNotFound
+	}
+	if err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+func (r *repository) FindByUserID(userID int) ([]Session, error) {
+	var sessions []Session
+
+	if err := r.db.Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *repository) Update(session Session) (Session, error) {
+	if err := r.db.Save(&session).Error; err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+func (r *repository) Delete(session Session) error {
+	return r.db.Delete(&session).Error
+}
+
+// DeleteByUserID deletes every session of the user but exceptID, pass 0 to
+// delete them all.
+func (r *repository) DeleteByUserID(userID int, exceptID int) (int64, error) {
+	result := r.db.Where("user_id = ? AND id <> ?", userID, exceptID).Delete(&Session{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
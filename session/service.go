@@ -0,0 +1,130 @@
+package session
+
+import "time"
+
+const (
+	// idleTimeout ends sessions that have not been used for a while
+	idleTimeout = 30 * 24 * time.Hour
+	// touchInterval keeps LastSeenAt accurate enough without writing on
+	// every request
+	touchInterval = time.Minute
+)
+
+type Service interface {
+	CreateSession(input CreateSessionInput) (Session, error)
+	CheckSession(ID int, userID int, ipAddress string) (Session, error)
+	GetSessions(userID int) ([]Session, error)
+	RevokeSession(input GetSessionInput) (Session, error)
+	RevokeSessions(userID int, exceptID int) (int64, error)
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(repository Repository) *service {
+	return &service{repository}
+}
+
+func (s *service) CreateSession(input CreateSessionInput) (Session, error) {
+	session := Session{
+		UserID:     input.UserID,
+		UserAgent:  truncate(input.UserAgent, 255),
+		IPAddress:  input.IPAddress,
+		LastSeenAt: time.Now(),
+	}
+
+	newSession, err := s.repository.Save(session)
+	if err != nil {
+		return newSession, err
+	}
+
+	return newSession, nil
+}
+
+// CheckSession makes sure the session a token was issued for is still
+// active, and records that it has just been used.
+func (s *service) CheckSession(ID int, userID int, ipAddress string) (Session, error) {
+	session, err := s.repository.FindByID(ID)
+	if err != nil {
+		return session, err
+	}
+
+	if session.UserID != userID {
+		return session, ErrSessionNotFound
+	}
+
+	now := time.Now()
+
+	if now.Sub(session.LastSeenAt) > idleTimeout {
+		if err := s.repository.Delete(session); err != nil {
+			return session, err
+		}
+
+		return session, ErrSessionExpired
+	}
+
+	if now.Sub(session.LastSeenAt) < touchInterval && session.IPAddress == ipAddress {
+		return session, nil
+	}
+
+	session.LastSeenAt = now
+	session.IPAddress = ipAddress
+
+	updatedSession, err := s.repository.Update(session)
+	if err != nil {
+		return updatedSession, err
+	}
+
+	return updatedSession, nil
+}
+
+func (s *service) GetSessions(userID int) ([]Session, error) {
+	sessions, err := s.repository.FindByUserID(userID)
+	if err != nil {
+		return sessions, err
+	}
+
+	// Sessions that timed out are only removed once their token is used,
+	// they are not active anymore either way
+	var activeSessions []Session
+	for _, session := range sessions {
+		if time.Since(session.LastSeenAt) <= idleTimeout {
+			activeSessions = append(activeSessions, session)
+		}
+	}
+
+	return activeSessions, nil
+}
+
+func (s *service) RevokeSession(input GetSessionInput) (Session, error) {
+	session, err := s.repository.FindByID(input.ID)
+	if err != nil {
+		return session, err
+	}
+
+	// Someone else's session does not exist as far as the user can tell
+	if session.UserID != input.User.ID {
+		return Session{}, ErrSessionNotFound
+	}
+
+	if err := s.repository.Delete(session); err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+// RevokeSessions signs the user out everywhere but exceptID, pass 0 to revoke
+// every session.
+func (s *service) RevokeSessions(userID int, exceptID int) (int64, error) {
+	return s.repository.DeleteByUserID(userID, exceptID)
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+
+	return value[:length]
+}
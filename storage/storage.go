@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"backer/apperror"
+	"io"
+	"time"
+)
+
+var ErrFileNotFound = apperror.NotFound("File not found")
+
+// URLResolver turns the key of a stored file into a URL clients can fetch.
+type URLResolver interface {
+	URL(key string) string
+}
+
+// Store keeps uploaded files under slash separated keys such as
+// "images/abc.jpg". Keys are what gets persisted in the database.
+type Store interface {
+	URLResolver
+	Put(key string, body io.Reader, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	SignedURL(key string, expiry time.Duration) (string, error)
+}
@@ -0,0 +1,63 @@
+package storage
+
+import "strings"
+
+type URLConfig struct {
+	// BaseURL is the public scheme and host of the API. It turns relative
+	// store URLs, such as those of the local store, into absolute ones.
+	BaseURL string
+	// CDNURL, when set, replaces the store's own URL for every file.
+	CDNURL string
+
+	AvatarPlaceholder        string
+	CampaignImagePlaceholder string
+}
+
+// URLBuilder builds the absolute URLs returned in API responses.
+type URLBuilder struct {
+	store  URLResolver
+	config URLConfig
+}
+
+func NewURLBuilder(store URLResolver, config URLConfig) *URLBuilder {
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	config.CDNURL = strings.TrimSuffix(config.CDNURL, "/")
+
+	return &URLBuilder{store, config}
+}
+
+func (b *URLBuilder) URL(key string) string {
+	if b.config.CDNURL != "" {
+		return b.config.CDNURL + "/" + escapePath(key)
+	}
+
+	return b.absolute(b.store.URL(key))
+}
+
+// AvatarURL returns the URL of an avatar, or the placeholder when the user
+// has none.
+func (b *URLBuilder) AvatarURL(key string) string {
+	if key == "" {
+		return b.absolute(b.config.AvatarPlaceholder)
+	}
+
+	return b.URL(key)
+}
+
+// CampaignImageURL returns the URL of a campaign image, or the placeholder
+// when the campaign has none.
+func (b *URLBuilder) CampaignImageURL(key string) string {
+	if key == "" {
+		return b.absolute(b.config.CampaignImagePlaceholder)
+	}
+
+	return b.URL(key)
+}
+
+func (b *URLBuilder) absolute(url string) string {
+	if strings.HasPrefix(url, "/") {
+		return b.config.BaseURL + url
+	}
+
+	return url
+}
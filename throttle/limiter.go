@@ -0,0 +1,94 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter counts failures per key in memory and blocks a key for a while
+// once it fails too often within a window.
+type Limiter struct {
+	mutex    sync.Mutex
+	entries  map[string]*entry
+	max      int
+	window   time.Duration
+	lockout  time.Duration
+	lastScan time.Time
+}
+
+type entry struct {
+	failures     int
+	windowStart  time.Time
+	blockedUntil time.Time
+}
+
+func NewLimiter(max int, window time.Duration, lockout time.Duration) *Limiter {
+	return &Limiter{
+		entries: make(map[string]*entry),
+		max:     max,
+		window:  window,
+		lockout: lockout,
+	}
+}
+
+// Allow reports whether key may make another attempt, and otherwise how
+// long it has to wait.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	e, ok := l.entries[key]
+	if !ok {
+		return true, 0
+	}
+
+	if wait := time.Until(e.blockedUntil); wait > 0 {
+		return false, wait
+	}
+
+	return true, 0
+}
+
+// Fail records a failed attempt of key.
+func (l *Limiter) Fail(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.cleanUp(now)
+
+	e, ok := l.entries[key]
+	if !ok || now.Sub(e.windowStart) > l.window {
+		e = &entry{windowStart: now}
+		l.entries[key] = e
+	}
+
+	e.failures++
+	if e.failures >= l.max {
+		e.blockedUntil = now.Add(l.lockout)
+	}
+}
+
+// Reset forgets the failures of key.
+func (l *Limiter) Reset(key string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	delete(l.entries, key)
+}
+
+// cleanUp drops expired entries once per window so the map does not grow
+// without bound.
+func (l *Limiter) cleanUp(now time.Time) {
+	if now.Sub(l.lastScan) < l.window {
+		return
+	}
+
+	for key, e := range l.entries {
+		if now.Sub(e.windowStart) > l.window && now.After(e.blockedUntil) {
+			delete(l.entries, key)
+		}
+	}
+
+	l.lastScan = now
+}
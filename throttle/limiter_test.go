@@ -0,0 +1,83 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterBlocksAfterMaxFailures(t *testing.T) {
+	limiter := NewLimiter(3, time.Minute, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		limiter.Fail("203.0.113.1")
+
+		if allowed, _ := limiter.Allow("203.0.113.1"); !allowed {
+			t.Fatalf("Allow() after %d failures = false, want true", i+1)
+		}
+	}
+
+	limiter.Fail("203.0.113.1")
+
+	allowed, wait := limiter.Allow("203.0.113.1")
+	if allowed {
+		t.Fatal("Allow() after 3 failures = true, want false")
+	}
+	if wait <= 0 || wait > time.Hour {
+		t.Errorf("Allow() wait = %v, want up to the lockout", wait)
+	}
+
+	if allowed, _ := limiter.Allow("203.0.113.2"); !allowed {
+		t.Error("Allow() of another key = false, want true")
+	}
+}
+
+func TestLimiterReset(t *testing.T) {
+	limiter := NewLimiter(1, time.Minute, time.Hour)
+
+	limiter.Fail("203.0.113.1")
+	limiter.Reset("203.0.113.1")
+
+	if allowed, _ := limiter.Allow("203.0.113.1"); !allowed {
+		t.Error("Allow() after Reset() = false, want true")
+	}
+}
+
+func TestLimiterForgetsFailuresOutsideTheWindow(t *testing.T) {
+	limiter := NewLimiter(2, 10*time.Millisecond, time.Hour)
+
+	limiter.Fail("203.0.113.1")
+	time.Sleep(20 * time.Millisecond)
+	limiter.Fail("203.0.113.1")
+
+	if allowed, _ := limiter.Allow("203.0.113.1"); !allowed {
+		t.Error("Allow() = false, want the first failure to have expired")
+	}
+}
+
+func TestLimiterUnblocksAfterLockout(t *testing.T) {
+	limiter := NewLimiter(1, time.Minute, 10*time.Millisecond)
+
+	limiter.Fail("203.0.113.1")
+
+	if allowed, _ := limiter.Allow("203.0.113.1"); allowed {
+		t.Fatal("Allow() = true, want false during the lockout")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if allowed, _ := limiter.Allow("203.0.113.1"); !allowed {
+		t.Error("Allow() after the lockout = false, want true")
+	}
+}
+
+func TestLimiterCleansUpExpiredEntries(t *testing.T) {
+	limiter := NewLimiter(5, 10*time.Millisecond, 10*time.Millisecond)
+
+	limiter.Fail("203.0.113.1")
+	time.Sleep(20 * time.Millisecond)
+	limiter.Fail("203.0.113.2")
+
+	if _, ok := limiter.entries["203.0.113.1"]; ok {
+		t.Error("entries still hold the expired key")
+	}
+}
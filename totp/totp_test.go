@@ -0,0 +1,143 @@
+package totp
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key of the RFC 6238 test vectors, base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateMatchesRFC6238(t *testing.T) {
+	// The RFC lists 8 digit codes, authenticator apps show their last 6
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.code, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, test.code, time.Unix(test.unix, 0))
+			if !ok {
+				t.Fatalf("Validate() rejected the code of %d", test.unix)
+			}
+
+			if want := test.unix / period; step != want {
+				t.Errorf("Validate() step = %d, want %d", step, want)
+			}
+		})
+	}
+}
+
+func TestValidateAllowsClockDrift(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code := "050471"
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		wantOK bool
+	}{
+		{"one period later", now.Add(period * time.Second), true},
+		{"one period earlier", now.Add(-period * time.Second), true},
+		{"two periods later", now.Add(2 * period * time.Second), false},
+		{"two periods earlier", now.Add(-2 * period * time.Second), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			step, ok := Validate(rfcSecret, code, test.at)
+			if ok != test.wantOK {
+				t.Fatalf("Validate() ok = %v, want %v", ok, test.wantOK)
+			}
+
+			// The step is the one the code belongs to, not the current one
+			if ok && step != now.Unix()/period {
+				t.Errorf("Validate() step = %d, want %d", step, now.Unix()/period)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsInvalidInput(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+	}{
+		{"wrong code", rfcSecret, "123456"},
+		{"too short", rfcSecret, "50471"},
+		{"too long", rfcSecret, "0050471"},
+		{"empty code", rfcSecret, ""},
+		{"invalid secret", "not base32!", "050471"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, ok := Validate(test.secret, test.code, now); ok {
+				t.Error("Validate() accepted the code")
+			}
+		})
+	}
+}
+
+func TestValidateAcceptsLowercaseSecret(t *testing.T) {
+	if _, ok := Validate(strings.ToLower(rfcSecret), "050471", time.Unix(1111111111, 0)); !ok {
+		t.Error("Validate() rejected a lowercase secret")
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+
+	key, err := encoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Fatalf("GenerateSecret() = %q, want 20 base32 encoded bytes", secret)
+	}
+
+	other, _ := GenerateSecret()
+	if other == secret {
+		t.Error("GenerateSecret() returned the same secret twice")
+	}
+
+	now := time.Now()
+	if _, ok := Validate(secret, generate(key, now.Unix()/period), now); !ok {
+		t.Error("Validate() rejected the current code of a generated secret")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri, err := url.Parse(ProvisioningURI("Backer", "ada@example.com", rfcSecret))
+	if err != nil {
+		t.Fatalf("ProvisioningURI() is not a URL: %v", err)
+	}
+
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Backer:ada@example.com" {
+		t.Errorf("ProvisioningURI() = %q, want otpauth://totp/Backer:ada@example.com", uri)
+	}
+
+	want := url.Values{
+		"secret":    {rfcSecret},
+		"issuer":    {"Backer"},
+		"algorithm": {"SHA1"},
+		"digits":    {"6"},
+		"period":    {"30"},
+	}
+	if got := uri.Query(); got.Encode() != want.Encode() {
+		t.Errorf("ProvisioningURI() query = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,21 @@
+package transaction
+
+import "time"
+
+const (
+	StatusPending = "pending"
+	StatusPaid    = "paid"
+)
+
+// Transaction is a pledge a user made to a campaign. Transactions are kept
+// for accounting, even after the user deletes their account.
+type Transaction struct {
+	ID         int
+	CampaignID int
+	UserID     int
+	Amount     int
+	Status     string
+	Code       string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,35 @@
+package transaction
+
+import "time"
+
+type TransactionFormatter struct {
+	ID         int       `json:"id"`
+	CampaignID int       `json:"campaign_id"`
+	Amount     int       `json:"amount"`
+	Status     string    `json:"status"`
+	Code       string    `json:"code"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func FormatTransaction(transaction Transaction) TransactionFormatter {
+	formatter := TransactionFormatter{
+		ID:         transaction.ID,
+		CampaignID: transaction.CampaignID,
+		Amount:     transaction.Amount,
+		Status:     transaction.Status,
+		Code:       transaction.Code,
+		CreatedAt:  transaction.CreatedAt,
+	}
+
+	return formatter
+}
+
+func FormatTransactions(transactions []Transaction) []TransactionFormatter {
+	formatters := []TransactionFormatter{}
+
+	for _, transaction := range transactions {
+		formatters = append(formatters, FormatTransaction(transaction))
+	}
+
+	return formatters
+}
@@ -0,0 +1,63 @@
+package transaction
+
+import (
+	"backer/user"
+
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	FindByUserID(userID int) ([]Transaction, error)
+	FindBackers(campaignID int) ([]user.User, error)
+	CountPaidByUser(campaignID int, userID int) (int64, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) *repository {
+	return &repository{db}
+}
+
+func (r *repository) FindByUserID(userID int) ([]Transaction, error) {
+	var transactions []Transaction
+
+	if err := r.db.Where("user_id = ?", userID).Order("id DESC").Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// FindBackers returns every user with a paid transaction for the campaign,
+// leaving out deleted accounts.
+func (r *repository) FindBackers(campaignID int) ([]user.User, error) {
+	var backers []user.User
+
+	paidUserIDs := r.db.
+		Model(&Transaction{}).
+		Select("user_id").
+		Where("campaign_id = ? AND status = ?", campaignID, StatusPaid)
+
+	if err := r.db.
+		Where("id IN (?) AND deleted_at IS NULL", paidUserIDs).
+		Find(&backers).Error; err != nil {
+		return nil, err
+	}
+
+	return backers, nil
+}
+
+func (r *repository) CountPaidByUser(campaignID int, userID int) (int64, error) {
+	var count int64
+
+	if err := r.db.
+		Model(&Transaction{}).
+		Where("campaign_id = ? AND user_id = ? AND status = ?", campaignID, userID, StatusPaid).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
@@ -0,0 +1,48 @@
+package transaction
+
+import "backer/user"
+
+type Service interface {
+	GetTransactionsByUserID(userID int) ([]Transaction, error)
+	GetBackers(campaignID int) ([]user.User, error)
+	IsBacker(campaignID int, userID int) (bool, error)
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(repository Repository) *service {
+	return &service{repository}
+}
+
+func (s *service) GetTransactionsByUserID(userID int) ([]Transaction, error) {
+	transactions, err := s.repository.FindByUserID(userID)
+	if err != nil {
+		return transactions, err
+	}
+
+	return transactions, nil
+}
+
+func (s *service) GetBackers(campaignID int) ([]user.User, error) {
+	backers, err := s.repository.FindBackers(campaignID)
+	if err != nil {
+		return backers, err
+	}
+
+	return backers, nil
+}
+
+func (s *service) IsBacker(campaignID int, userID int) (bool, error) {
+	if userID == 0 {
+		return false, nil
+	}
+
+	paidTransactions, err := s.repository.CountPaidByUser(campaignID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return paidTransactions > 0, nil
+}
@@ -0,0 +1,144 @@
+package upload
+
+import (
+	"backer/apperror"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	_ "golang.org/x/image/webp"
+)
+
+// Storage key prefixes of uploaded images
+const (
+	AvatarDir              = "images"
+	CampaignImageDir       = "campaign-images"
+	CampaignUpdateImageDir = "campaign-update-images"
+)
+
+// ImageRules limit uploads. A few KB of compressed image can decode into
+// hundreds of MB of pixels, so besides the file size the pixel count is
+// capped too, which bounds the memory processing takes.
+type ImageRules struct {
+	MaxSize      int64
+	MaxWidth     int
+	MaxHeight    int
+	MaxPixels    int
+	ContentTypes []string
+}
+
+var AvatarRules = ImageRules{
+	MaxSize:   2 << 20,
+	MaxWidth:  4032,
+	MaxHeight: 4032,
+	// A 12 megapixel phone photo
+	MaxPixels:    12_500_000,
+	ContentTypes: []string{"image/jpeg", "image/png", "image/webp"},
+}
+
+var CampaignImageRules = ImageRules{
+	MaxSize:   8 << 20,
+	MaxWidth:  6000,
+	MaxHeight: 6000,
+	// A 24 megapixel camera photo
+	MaxPixels:    24_000_000,
+	ContentTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
+}
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type Image struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+}
+
+// ReadImage reads an uploaded file and checks it against rules. The content
+// type is sniffed from the file itself; the client-supplied name and
+// Content-Type header are ignored.
+func ReadImage(file *multipart.FileHeader, rules ImageRules) (Image, error) {
+	if file.Size > rules.MaxSize {
+		return Image{}, apperror.Validation(fmt.Sprintf("File must not be larger than %d KB", rules.MaxSize>>10))
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return Image{}, err
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, rules.MaxSize+1))
+	if err != nil {
+		return Image{}, err
+	}
+
+	if int64(len(data)) > rules.MaxSize {
+		return Image{}, apperror.Validation(fmt.Sprintf("File must not be larger than %d KB", rules.MaxSize>>10))
+	}
+
+	contentType := http.DetectContentType(data)
+	if !rules.allows(contentType) {
+		return Image{}, apperror.Validation(fmt.Sprintf("File type %s is not allowed", contentType))
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, apperror.Validation("File is not a valid image")
+	}
+
+	if config.Width > rules.MaxWidth || config.Height > rules.MaxHeight {
+		return Image{}, apperror.Validation(fmt.Sprintf("Image must not be larger than %dx%d pixels", rules.MaxWidth, rules.MaxHeight))
+	}
+
+	if config.Width*config.Height > rules.MaxPixels {
+		return Image{}, apperror.Validation(fmt.Sprintf("Image must not have more than %g megapixels", float64(rules.MaxPixels)/1e6))
+	}
+
+	uploadedImage := Image{
+		Data:        data,
+		ContentType: contentType,
+		Extension:   extensions[contentType],
+		Width:       config.Width,
+		Height:      config.Height,
+	}
+
+	return uploadedImage, nil
+}
+
+func (r ImageRules) allows(contentType string) bool {
+	for _, allowed := range r.ContentTypes {
+		if allowed == contentType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// randomNameLength is the number of random bytes in a file name
+const randomNameLength = 16
+
+// RandomFileName returns a hex encoded random name with the given extension.
+func RandomFileName(extension string) (string, error) {
+	name := make([]byte, randomNameLength)
+	if _, err := rand.Read(name); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(name) + extension, nil
+}
@@ -0,0 +1,103 @@
+package upload
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"strings"
+	"testing"
+)
+
+// fileHeader uploads data as a multipart file, the way a handler receives it.
+func fileHeader(t *testing.T, data []byte) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", "upload.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	writer.Close()
+
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return form.File["file"][0]
+}
+
+func encodePNG(t *testing.T, width int, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, img); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+func TestReadImage(t *testing.T) {
+	rules := ImageRules{
+		MaxSize:      4 << 10,
+		MaxWidth:     100,
+		MaxHeight:    100,
+		MaxPixels:    5000,
+		ContentTypes: []string{"image/png"},
+	}
+
+	validPNG := encodePNG(t, 50, 40)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"valid", validPNG, ""},
+		{"too large file", append(append([]byte{}, validPNG...), make([]byte, 4<<10)...), "must not be larger than 4 KB"},
+		{"text file", []byte("just some text"), "File type text/plain; charset=utf-8 is not allowed"},
+		{"type not allowed", []byte("GIF89a" + strings.Repeat("\x00", 20)), "File type image/gif is not allowed"},
+		{"broken image", validPNG[:30], "File is not a valid image"},
+		{"too wide", encodePNG(t, 101, 1), "must not be larger than 100x100 pixels"},
+		{"too tall", encodePNG(t, 1, 101), "must not be larger than 100x100 pixels"},
+		{"too many pixels", encodePNG(t, 100, 100), "must not have more than 0.005 megapixels"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img, err := ReadImage(fileHeader(t, test.data), rules)
+
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ReadImage() error = %v", err)
+				}
+
+				if img.ContentType != "image/png" || img.Extension != ".png" || img.Width != 50 || img.Height != 40 {
+					t.Errorf("ReadImage() = %s %s %dx%d, want image/png .png 50x40", img.ContentType, img.Extension, img.Width, img.Height)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("ReadImage() error = %v, want %q", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestImageRulesCapPixels(t *testing.T) {
+	for _, rules := range []ImageRules{AvatarRules, CampaignImageRules} {
+		if rules.MaxPixels <= 0 || rules.MaxPixels >= rules.MaxWidth*rules.MaxHeight {
+			t.Errorf("MaxPixels = %d, want a cap below %dx%d", rules.MaxPixels, rules.MaxWidth, rules.MaxHeight)
+		}
+	}
+}
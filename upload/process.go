@@ -0,0 +1,178 @@
+package upload
+
+import (
+	"backer/apperror"
+	"bytes"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"path"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+type Rendition struct {
+	Name   string
+	Width  int
+	Height int
+	Crop   bool
+}
+
+// Renditions are generated for every uploaded image. The full rendition
+// keeps the original aspect ratio, the others are cropped to fill their box.
+var Renditions = []Rendition{
+	{Name: "thumbnail", Width: 200, Height: 200, Crop: true},
+	{Name: "card", Width: 600, Height: 400, Crop: true},
+	{Name: "full", Width: 1600, Height: 1600},
+}
+
+// ProcessedExtension is the extension of every processed rendition.
+const ProcessedExtension = ".jpg"
+
+const jpegQuality = 85
+
+// Process decodes img and re-encodes each rendition as JPEG. Encoding from
+// raw pixels drops every metadata block, EXIF and GPS included, so the EXIF
+// orientation is applied to the pixels beforehand. Each rendition is resized
+// first and oriented afterwards, so only the small copy is transformed.
+func Process(img Image) (map[string][]byte, error) {
+	decoded, _, err := image.Decode(bytes.NewReader(img.Data))
+	if err != nil {
+		return nil, apperror.Validation("File is not a valid image")
+	}
+
+	orientation := exifOrientation(img.Data)
+
+	renditions := make(map[string][]byte)
+
+	for _, rendition := range Renditions {
+		var buffer bytes.Buffer
+
+		// Orientations from 5 on turn the image by a quarter, so the box
+		// is turned too before resizing the unturned source
+		box := rendition
+		if orientation >= 5 && orientation <= 8 {
+			box.Width, box.Height = rendition.Height, rendition.Width
+		}
+
+		resized := orient(resize(decoded, box), orientation)
+		if err := jpeg.Encode(&buffer, resized, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+
+		renditions[rendition.Name] = buffer.Bytes()
+	}
+
+	return renditions, nil
+}
+
+// HasRenditions reports whether the image stored at filePath was processed
+// into renditions, which only images named by RandomFileName with
+// ProcessedExtension are. Images uploaded before only have the original file.
+func HasRenditions(filePath string) bool {
+	name := path.Base(filePath)
+	if path.Ext(name) != ProcessedExtension {
+		return false
+	}
+
+	id, err := hex.DecodeString(strings.TrimSuffix(name, ProcessedExtension))
+
+	return err == nil && len(id) == randomNameLength
+}
+
+// RenditionPath returns where a rendition of the image stored at filePath
+// lives. The full rendition is stored at filePath itself, and so is every
+// rendition of an image without renditions.
+func RenditionPath(filePath string, rendition string) string {
+	if rendition == "full" || !HasRenditions(filePath) {
+		return filePath
+	}
+
+	extension := path.Ext(filePath)
+
+	return strings.TrimSuffix(filePath, extension) + "-" + rendition + extension
+}
+
+// RenditionPaths lists the path of every rendition of an image.
+func RenditionPaths(filePath string) []string {
+	if !HasRenditions(filePath) {
+		return []string{filePath}
+	}
+
+	var paths []string
+
+	for _, rendition := range Renditions {
+		paths = append(paths, RenditionPath(filePath, rendition.Name))
+	}
+
+	return paths
+}
+
+// RenditionURLs maps every rendition name to its URL as built by url. When
+// there is no image url receives an empty key, so it can return a
+// placeholder.
+func RenditionURLs(filePath string, url func(key string) string) map[string]string {
+	renditionURLs := make(map[string]string)
+
+	for _, rendition := range Renditions {
+		key := ""
+		if filePath != "" {
+			key = RenditionPath(filePath, rendition.Name)
+		}
+
+		renditionURLs[rendition.Name] = url(key)
+	}
+
+	return renditionURLs
+}
+
+func resize(src image.Image, rendition Rendition) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	var srcRect image.Rectangle
+	var dstWidth, dstHeight int
+
+	if rendition.Crop {
+		// Crop the largest centered area with the rendition's aspect ratio
+		cropWidth, cropHeight := width, width*rendition.Height/rendition.Width
+		if cropHeight > height {
+			cropWidth, cropHeight = height*rendition.Width/rendition.Height, height
+		}
+
+		x := bounds.Min.X + (width-cropWidth)/2
+		y := bounds.Min.Y + (height-cropHeight)/2
+		srcRect = image.Rect(x, y, x+cropWidth, y+cropHeight)
+
+		dstWidth, dstHeight = rendition.Width, rendition.Height
+		if cropWidth < dstWidth {
+			dstWidth, dstHeight = cropWidth, cropHeight
+		}
+	} else {
+		srcRect = bounds
+
+		dstWidth, dstHeight = width, height
+		if dstWidth > rendition.Width {
+			dstWidth, dstHeight = rendition.Width, height*rendition.Width/width
+		}
+		if dstHeight > rendition.Height {
+			dstWidth, dstHeight = dstWidth*rendition.Height/dstHeight, rendition.Height
+		}
+	}
+
+	if dstWidth < 1 {
+		dstWidth = 1
+	}
+	if dstHeight < 1 {
+		dstHeight = 1
+	}
+
+	// JPEG has no alpha channel, so transparent areas are flattened on white
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, srcRect, draw.Over, nil)
+
+	return dst
+}
@@ -0,0 +1,156 @@
+package upload
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"reflect"
+	"testing"
+)
+
+func TestRenditionPath(t *testing.T) {
+	processed := "campaign-images/0123456789abcdef0123456789abcdef.jpg"
+
+	tests := []struct {
+		name      string
+		filePath  string
+		rendition string
+		want      string
+	}{
+		{"thumbnail", processed, "thumbnail", "campaign-images/0123456789abcdef0123456789abcdef-thumbnail.jpg"},
+		{"card", processed, "card", "campaign-images/0123456789abcdef0123456789abcdef-card.jpg"},
+		{"full", processed, "full", processed},
+		{"legacy upload", "images/5-avatar.png", "thumbnail", "images/5-avatar.png"},
+		{"legacy JPEG upload", "campaign-images/5-cover.jpg", "card", "campaign-images/5-cover.jpg"},
+		{"random name of another format", "images/0123456789abcdef0123456789abcdef.png", "thumbnail", "images/0123456789abcdef0123456789abcdef.png"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := RenditionPath(test.filePath, test.rendition); got != test.want {
+				t.Errorf("RenditionPath(%q, %q) = %q, want %q", test.filePath, test.rendition, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRenditionPathsOfLegacyUpload(t *testing.T) {
+	want := []string{"images/5-avatar.png"}
+
+	if got := RenditionPaths("images/5-avatar.png"); !reflect.DeepEqual(got, want) {
+		t.Errorf("RenditionPaths() = %v, want %v", got, want)
+	}
+}
+
+func TestRenditionURLs(t *testing.T) {
+	url := func(key string) string {
+		if key == "" {
+			return "placeholder.png"
+		}
+
+		return "https://cdn.example/" + key
+	}
+
+	tests := []struct {
+		name     string
+		filePath string
+		want     map[string]string
+	}{
+		{
+			name:     "no image",
+			filePath: "",
+			want:     map[string]string{"thumbnail": "placeholder.png", "card": "placeholder.png", "full": "placeholder.png"},
+		},
+		{
+			name:     "legacy upload",
+			filePath: "images/5-avatar.png",
+			want: map[string]string{
+				"thumbnail": "https://cdn.example/images/5-avatar.png",
+				"card":      "https://cdn.example/images/5-avatar.png",
+				"full":      "https://cdn.example/images/5-avatar.png",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := RenditionURLs(test.filePath, url); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("RenditionURLs() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+// withOrientation inserts an EXIF block with the orientation tag right
+// after the start of image marker of a JPEG.
+func withOrientation(jpegData []byte, orientation byte) []byte {
+	exif := []byte("Exif\x00\x00" +
+		"MM\x00\x2a\x00\x00\x00\x08" + // big endian TIFF header, IFD at 8
+		"\x00\x01" + // one entry
+		"\x01\x12\x00\x03\x00\x00\x00\x01\x00" + string([]byte{orientation}) + "\x00\x00" +
+		"\x00\x00\x00\x00") // no next IFD
+
+	segment := append([]byte{0xFF, 0xE1, 0, byte(len(exif) + 2)}, exif...)
+
+	return append(append([]byte{0xFF, 0xD8}, segment...), jpegData[2:]...)
+}
+
+func TestProcessAppliesOrientation(t *testing.T) {
+	// 40x20 with a red left half
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			c := color.RGBA{B: 255, A: 255}
+			if x < 20 {
+				c = color.RGBA{R: 255, A: 255}
+			}
+			src.SetRGBA(x, y, c)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, src, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		orientation byte
+		wantWidth   int
+		wantHeight  int
+		// redAt is a point well inside the red half after orienting
+		redAt image.Point
+	}{
+		{"upright", 1, 40, 20, image.Point{X: 5, Y: 10}},
+		{"mirrored", 2, 40, 20, image.Point{X: 35, Y: 10}},
+		{"turned clockwise", 6, 20, 40, image.Point{X: 10, Y: 5}},
+		{"turned counterclockwise", 8, 20, 40, image.Point{X: 10, Y: 35}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			renditions, err := Process(Image{Data: withOrientation(buffer.Bytes(), test.orientation)})
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			full, err := jpeg.Decode(bytes.NewReader(renditions["full"]))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if size := full.Bounds().Size(); size.X != test.wantWidth || size.Y != test.wantHeight {
+				t.Fatalf("full rendition = %dx%d, want %dx%d", size.X, size.Y, test.wantWidth, test.wantHeight)
+			}
+
+			if r, _, b, _ := full.At(test.redAt.X, test.redAt.Y).RGBA(); r < b {
+				t.Errorf("pixel at %v is not red", test.redAt)
+			}
+
+			if _, ok := renditions["thumbnail"]; !ok {
+				t.Error("Process() left out the thumbnail")
+			}
+		})
+	}
+}
@@ -2,14 +2,55 @@ package user
 
 import "time"
 
+// User is an account. Deleting it anonymises the row and sets DeletedAt,
+// after which the repository no longer finds it.
 type User struct {
-	ID             int
-	Name           string
-	Occupation     string
-	Email          string
-	PasswordHash   string
-	AvatarFileName string
-	Role           string
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	ID                 int
+	Name               string
+	Occupation         string
+	Email              string
+	EmailVerifiedAt    *time.Time
+	UnconfirmedEmail   string
+	PasswordHash       string
+	PasswordChangedAt  *time.Time
+	FailedLogins       int
+	LockedUntil        *time.Time
+	TOTPSecret         string
+	TOTPLastStep       int64
+	TwoFactorEnabledAt *time.Time
+	AvatarFileName     string
+	Role               string
+	DeletedAt          *time.Time
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+type PasswordReset struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type RecoveryCode struct {
+	ID        int
+	UserID    int
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Identity links a user to their account at an external login provider.
+type Identity struct {
+	ID        int
+	UserID    int
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
@@ -0,0 +1,26 @@
+package user
+
+import "backer/apperror"
+
+var (
+	ErrUserNotFound       = apperror.NotFound("User not found")
+	ErrEmailRegistered    = apperror.Conflict("Email has been registered")
+	ErrInvalidCredentials = apperror.Unauthorized("Invalid email or password")
+	ErrTooManyLogins      = apperror.RateLimited("Too many failed login attempts, try again later")
+	ErrInvalidEmailToken  = apperror.Validation("Email verification link is invalid or has expired")
+	ErrWrongPassword      = apperror.Validation("Current password is wrong")
+	ErrEmailVerified      = apperror.Conflict("Email has already been verified")
+	ErrEmailNotVerified   = apperror.Forbidden("Email has not been verified")
+	ErrReauthenticate     = apperror.Unauthorized("Confirm with your password or a two-factor code, or sign in again")
+
+	ErrInvalidPasswordResetToken = apperror.Validation("Password reset link is invalid or has expired")
+
+	ErrTwoFactorEnabled    = apperror.Conflict("Two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled = apperror.Conflict("Two-factor authentication is not enabled")
+	ErrTwoFactorNotSetUp   = apperror.Conflict("Two-factor authentication has not been set up")
+	ErrInvalidTwoFactor    = apperror.Unauthorized("Invalid two-factor authentication code")
+	ErrTwoFactorRequired   = apperror.Forbidden("Two-factor authentication must be enabled")
+
+	ErrIdentityNotFound         = apperror.NotFound("Identity not found")
+	ErrProviderEmailNotVerified = apperror.Forbidden("The login provider has not verified the email address")
+)
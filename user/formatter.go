@@ -1,20 +1,88 @@
 package user
 
+import (
+	"backer/storage"
+	"backer/upload"
+	"time"
+)
+
 type UserFormatter struct {
-	ID         int    `json:"id"`
-	Name       string `json:"name"`
-	Occupation string `json:"occupation"`
-	Email      string `json:"email"`
-	Token      string `json:"token"`
+	ID                 int               `json:"id"`
+	Name               string            `json:"name"`
+	Occupation         string            `json:"occupation"`
+	Email              string            `json:"email"`
+	IsEmailVerified    bool              `json:"is_email_verified"`
+	IsTwoFactorEnabled bool              `json:"is_two_factor_enabled"`
+	UnconfirmedEmail   string            `json:"unconfirmed_email,omitempty"`
+	AvatarURL          string            `json:"avatar_url"`
+	AvatarRenditions   map[string]string `json:"avatar_renditions"`
+	Token              string            `json:"token,omitempty"`
 }
 
-func FormatUser(user User, token string) UserFormatter {
+func FormatUser(user User, token string, urls *storage.URLBuilder) UserFormatter {
 	formatter := UserFormatter{
-		ID:         user.ID,
-		Name:       user.Name,
-		Occupation: user.Occupation,
-		Email:      user.Email,
-		Token:      token,
+		ID:                 user.ID,
+		Name:               user.Name,
+		Occupation:         user.Occupation,
+		Email:              user.Email,
+		IsEmailVerified:    user.EmailVerifiedAt != nil,
+		IsTwoFactorEnabled: user.TwoFactorEnabledAt != nil,
+		UnconfirmedEmail:   user.UnconfirmedEmail,
+		AvatarURL:          urls.AvatarURL(user.AvatarFileName),
+		AvatarRenditions:   upload.RenditionURLs(user.AvatarFileName, urls.AvatarURL),
+		Token:              token,
+	}
+
+	return formatter
+}
+
+// UserExportFormatter is everything stored about the user, for the data
+// export.
+type UserExportFormatter struct {
+	ID                 int                       `json:"id"`
+	Name               string                    `json:"name"`
+	Occupation         string                    `json:"occupation"`
+	Email              string                    `json:"email"`
+	EmailVerifiedAt    *time.Time                `json:"email_verified_at"`
+	UnconfirmedEmail   string                    `json:"unconfirmed_email"`
+	TwoFactorEnabledAt *time.Time                `json:"two_factor_enabled_at"`
+	AvatarFileName     string                    `json:"avatar_file_name"`
+	Role               string                    `json:"role"`
+	Identities         []IdentityExportFormatter `json:"identities"`
+	CreatedAt          time.Time                 `json:"created_at"`
+	UpdatedAt          time.Time                 `json:"updated_at"`
+}
+
+type IdentityExportFormatter struct {
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func FormatUserExport(user User, identities []Identity) UserExportFormatter {
+	formatter := UserExportFormatter{
+		ID:                 user.ID,
+		Name:               user.Name,
+		Occupation:         user.Occupation,
+		Email:              user.Email,
+		EmailVerifiedAt:    user.EmailVerifiedAt,
+		UnconfirmedEmail:   user.UnconfirmedEmail,
+		TwoFactorEnabledAt: user.TwoFactorEnabledAt,
+		AvatarFileName:     user.AvatarFileName,
+		Role:               user.Role,
+		Identities:         []IdentityExportFormatter{},
+		CreatedAt:          user.CreatedAt,
+		UpdatedAt:          user.UpdatedAt,
+	}
+
+	for _, identity := range identities {
+		formatter.Identities = append(formatter.Identities, IdentityExportFormatter{
+			Provider:  identity.Provider,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
 	}
 
 	return formatter
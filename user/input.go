@@ -1,5 +1,7 @@
 package user
 
+import "time"
+
 type RegisterUserInput struct {
 	Name       string `json:"name" binding:"required"`
 	Occupation string `json:"occupation" binding:"required"`
@@ -8,10 +10,67 @@ type RegisterUserInput struct {
 }
 
 type LoginInput struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required"`
+	IPAddress string `json:"-"`
+}
+
+type GetUserInput struct {
+	ID int `uri:"id" binding:"required"`
 }
 
 type CheckEmailInput struct {
 	Email string `json:"email" binding:"required,email"`
 }
+
+type UpdateProfileInput struct {
+	Name       string `json:"name" binding:"required"`
+	Occupation string `json:"occupation" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+}
+
+type ConfirmEmailInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=8"`
+}
+
+type RequestPasswordResetInput struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordInput struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8"`
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type VerifyTwoFactorInput struct {
+	Token     string `json:"two_factor_token" binding:"required"`
+	Code      string `json:"code" binding:"required"`
+	IPAddress string `json:"-"`
+}
+
+// DeleteAccountInput confirms the deletion with the password, a two-factor
+// code, or a login moments ago. Users who signed up with a provider never
+// learnt their password, so they sign in again or use their second factor.
+type DeleteAccountInput struct {
+	Password   string    `json:"password"`
+	Code       string    `json:"code"`
+	LoggedInAt time.Time `json:"-"`
+}
+
+// ProviderLoginInput is the identity an external login provider vouched for.
+type ProviderLoginInput struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
@@ -0,0 +1,51 @@
+package user
+
+import (
+	"backer/mailer"
+	"net/url"
+	"strings"
+)
+
+// Notifier delivers account related messages to users.
+type Notifier interface {
+	// SendEmailVerification asks the owner of email to confirm it with
+	// token.
+	SendEmailVerification(user User, email string, token string) error
+	// SendPasswordReset sends the single-use token resetting the user's
+	// password.
+	SendPasswordReset(user User, token string) error
+}
+
+type mailNotifier struct {
+	mailer mailer.Mailer
+	appURL string
+}
+
+// NewMailNotifier sends account emails with links into the web app served
+// at appURL.
+func NewMailNotifier(mailer mailer.Mailer, appURL string) *mailNotifier {
+	return &mailNotifier{mailer, strings.TrimSuffix(appURL, "/")}
+}
+
+func (n *mailNotifier) SendEmailVerification(user User, email string, token string) error {
+	return n.send(email, mailer.TemplateEmailVerification, map[string]string{
+		"Name": user.Name,
+		"URL":  n.appURL + "/verify-email?token=" + url.QueryEscape(token),
+	})
+}
+
+func (n *mailNotifier) SendPasswordReset(user User, token string) error {
+	return n.send(user.Email, mailer.TemplatePasswordReset, map[string]string{
+		"Name": user.Name,
+		"URL":  n.appURL + "/reset-password?token=" + url.QueryEscape(token),
+	})
+}
+
+func (n *mailNotifier) send(to string, template string, data interface{}) error {
+	message, err := mailer.NewMessage(to, template, data)
+	if err != nil {
+		return err
+	}
+
+	return n.mailer.Send(message)
+}
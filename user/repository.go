@@ -1,12 +1,31 @@
 package user
 
-import "gorm.io/gorm"
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Repository interface {
 	Save(user User) (User, error)
 	FindByEmail(email string) (User, error)
 	FindByID(id int) (User, error)
 	Update(user User) (User, error)
+	IncrementFailedLogins(id int) (User, error)
+	LockUntil(id int, lockedUntil time.Time) error
+	ResetFailedLogins(id int) error
+	SavePasswordReset(passwordReset PasswordReset) (PasswordReset, error)
+	FindPasswordResetByTokenHash(tokenHash string) (PasswordReset, error)
+	ResetPassword(passwordReset PasswordReset, user User) (User, error)
+	SaveRecoveryCodes(userID int, recoveryCodes []RecoveryCode) error
+	FindRecoveryCodes(userID int) ([]RecoveryCode, error)
+	UseRecoveryCode(id int, usedAt time.Time) (bool, error)
+	AdvanceTOTPStep(id int, step int64) (bool, error)
+	FindIdentity(provider string, subject string) (Identity, error)
+	SaveIdentity(identity Identity) (Identity, error)
+	FindIdentities(userID int) ([]Identity, error)
+	Anonymize(user User) (User, error)
 }
 
 type repository struct {
@@ -29,7 +48,10 @@ func (r *repository) Save(user User) (User, error) {
 func (r *repository) FindByEmail(email string) (User, error) {
 	var user User
 
-	err := r.db.Where("email = ?", email).Find(&user).Error
+	err := r.db.Where("email = ? AND deleted_at IS NULL", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, ErrUserNotFound
+	}
 	if err != nil {
 		return user, err
 	}
@@ -37,10 +59,15 @@ func (r *repository) FindByEmail(email string) (User, error) {
 	return user, nil
 }
 
+// FindByID finds a user who has not deleted their account. Deleted users
+// only show up as the author of what they left behind.
 func (r *repository) FindByID(id int) (User, error) {
 	var user User
 
-	err := r.db.Where("id = ?", id).Find(&user).Error
+	err := r.db.Where("id = ? AND deleted_at IS NULL", id).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, ErrUserNotFound
+	}
 	if err != nil {
 		return user, err
 	}
@@ -55,3 +82,190 @@ func (r *repository) Update(user User) (User, error) {
 
 	return user, nil
 }
+
+// IncrementFailedLogins counts a failed login in the database itself, so
+// concurrent failures are never lost, and returns the updated user.
+func (r *repository) IncrementFailedLogins(id int) (User, error) {
+	if err := r.db.
+		Model(&User{}).
+		Where("id = ?", id).
+		UpdateColumn("failed_logins", gorm.Expr("failed_logins + 1")).Error; err != nil {
+		return User{}, err
+	}
+
+	return r.FindByID(id)
+}
+
+func (r *repository) LockUntil(id int, lockedUntil time.Time) error {
+	return r.db.Model(&User{}).Where("id = ?", id).UpdateColumn("locked_until", lockedUntil).Error
+}
+
+func (r *repository) ResetFailedLogins(id int) error {
+	return r.db.
+		Model(&User{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"failed_logins": 0, "locked_until": nil}).Error
+}
+
+func (r *repository) SavePasswordReset(passwordReset PasswordReset) (PasswordReset, error) {
+	if err := r.db.Create(&passwordReset).Error; err != nil {
+		return passwordReset, err
+	}
+
+	return passwordReset, nil
+}
+
+func (r *repository) FindPasswordResetByTokenHash(tokenHash string) (PasswordReset, error) {
+	var passwordReset PasswordReset
+
+	err := r.db.Where("token_hash = ?", tokenHash).First(&passwordReset).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return passwordReset, ErrInvalidPasswordResetToken
+	}
+	if err != nil {
+		return passwordReset, err
+	}
+
+	return passwordReset, nil
+}
+
+// ResetPassword uses up the reset and stores the new password of the user
+// in one transaction. A reset which is already used fails with
+// ErrInvalidPasswordResetToken, so a token never works twice.
+func (r *repository) ResetPassword(passwordReset PasswordReset, user User) (User, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&PasswordReset{}).
+			Where("id = ? AND used_at IS NULL", passwordReset.ID).
+			UpdateColumn("used_at", passwordReset.UsedAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return ErrInvalidPasswordResetToken
+		}
+
+		return tx.Model(&user).Updates(map[string]interface{}{
+			"password_hash":       user.PasswordHash,
+			"password_changed_at": user.PasswordChangedAt,
+		}).Error
+	})
+	if err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+// SaveRecoveryCodes replaces every recovery code of the user.
+func (r *repository) SaveRecoveryCodes(userID int, recoveryCodes []RecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		if len(recoveryCodes) == 0 {
+			return nil
+		}
+
+		return tx.Create(&recoveryCodes).Error
+	})
+}
+
+func (r *repository) FindRecoveryCodes(userID int) ([]RecoveryCode, error) {
+	var recoveryCodes []RecoveryCode
+
+	if err := r.db.Where("user_id = ? AND used_at IS NULL", userID).Find(&recoveryCodes).Error; err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+// UseRecoveryCode marks the code used unless it already is. It reports
+// whether this call used it, so a code is only ever accepted once.
+func (r *repository) UseRecoveryCode(id int, usedAt time.Time) (bool, error) {
+	result := r.db.
+		Model(&RecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		UpdateColumn("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// AdvanceTOTPStep records the time step of an accepted TOTP code unless a
+// code of the same or a later step was accepted already. It reports whether
+// the step was recorded.
+func (r *repository) AdvanceTOTPStep(id int, step int64) (bool, error) {
+	result := r.db.
+		Model(&User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		UpdateColumn("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (r *repository) FindIdentity(provider string, subject string) (Identity, error) {
+	var identity Identity
+
+	err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return identity, ErrIdentityNotFound
+	}
+	if err != nil {
+		return identity, err
+	}
+
+	return identity, nil
+}
+
+func (r *repository) SaveIdentity(identity Identity) (Identity, error) {
+	if err := r.db.Create(&identity).Error; err != nil {
+		return identity, err
+	}
+
+	return identity, nil
+}
+
+func (r *repository) FindIdentities(userID int) ([]Identity, error) {
+	var identities []Identity
+
+	if err := r.db.Where("user_id = ?", userID).Find(&identities).Error; err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// Anonymize saves the scrubbed user and deletes everything else that could
+// identify them or log them in, sessions and API keys included, so no token
+// or key outlives the account.
+func (r *repository) Anonymize(user User) (User, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []interface{}{&RecoveryCode{}, &PasswordReset{}, &Identity{}} {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		// Both packages import this one, so their tables are named here
+		for _, table := range []string{"sessions", "api_keys"} {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE user_id = ?", user.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Save(&user).Error
+	})
+	if err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
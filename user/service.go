@@ -1,9 +1,20 @@
 package user
 
 import (
+	"backer/auth"
+	"backer/throttle"
+	"backer/totp"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-
-	"golang.org/x/crypto/bcrypt"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 )
 
 type Service interface {
@@ -12,28 +23,75 @@ type Service interface {
 	IsEmailAvailable(input CheckEmailInput) (bool, error)
 	SaveAvatar(id int, fileLocation string) (User, error)
 	GetUserByID(id int) (User, error)
+	UpdateProfile(id int, input UpdateProfileInput) (User, error)
+	ConfirmEmail(input ConfirmEmailInput) (User, error)
+	ResendEmailVerification(id int) error
+	ChangePassword(id int, input ChangePasswordInput) (User, error)
+	RequestPasswordReset(input RequestPasswordResetInput) error
+	ResetPassword(input ResetPasswordInput) (User, error)
+	UnlockUser(id int) (User, error)
+	SetUpTwoFactor(id int) (User, string, error)
+	EnableTwoFactor(id int, input TwoFactorCodeInput) ([]string, error)
+	DisableTwoFactor(id int, input TwoFactorCodeInput) (User, error)
+	VerifyTwoFactor(input VerifyTwoFactorInput) (User, error)
+	LoginWithProvider(input ProviderLoginInput) (User, error)
+	GetIdentities(userID int) ([]Identity, error)
+	DeleteAccount(id int, input DeleteAccountInput) (User, error)
 }
 
+const (
+	passwordResetLifetime = time.Hour
+
+	// An account is locked after maxFailedLogins consecutive failures, for
+	// a lockout doubling with every further failure up to maxLockout
+	maxFailedLogins = 5
+	baseLockout     = time.Minute
+	maxLockout      = time.Hour
+
+	// A session this young counts as having just entered the credentials
+	recentLoginWindow = 10 * time.Minute
+
+	totpIssuer        = "Backer"
+	recoveryCodeCount = 10
+)
+
 type service struct {
-	repository Repository
+	repository   Repository
+	authService  auth.Service
+	notifier     Notifier
+	hasher       PasswordHasher
+	loginLimiter *throttle.Limiter
+	dummyHash    string
 }
 
-func NewService(repository Repository) *service {
-	return &service{repository}
+// NewService creates the user service. loginLimiter throttles failed logins
+// per IP address.
+func NewService(repository Repository, authService auth.Service, notifier Notifier, hasher PasswordHasher, loginLimiter *throttle.Limiter) *service {
+	dummyHash, _ := hasher.Hash("dummy password")
+
+	return &service{repository, authService, notifier, hasher, loginLimiter, dummyHash}
 }
 
 func (s *service) RegisterUser(input RegisterUserInput) (User, error) {
+	_, err := s.repository.FindByEmail(input.Email)
+	if err == nil {
+		return User{}, ErrEmailRegistered
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return User{}, err
+	}
+
 	user := User{}
 	user.Name = input.Name
 	user.Occupation = input.Occupation
 	user.Email = input.Email
 
-	passwordHashInByte, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.MinCost)
+	passwordHash, err := s.hasher.Hash(input.Password)
 	if err != nil {
 		return user, err
 	}
 
-	user.PasswordHash = string(passwordHashInByte)
+	user.PasswordHash = passwordHash
 	user.Role = "user"
 
 	newUser, err := s.repository.Save(user)
@@ -41,6 +99,12 @@ func (s *service) RegisterUser(input RegisterUserInput) (User, error) {
 		return user, err
 	}
 
+	// The account exists at this point, a failed delivery can be retried
+	// through the resend endpoint
+	if err := s.sendEmailVerification(newUser, newUser.Email); err != nil {
+		log.Printf("failed to send email verification to user %d: %v", newUser.ID, err)
+	}
+
 	return newUser, nil
 }
 
@@ -48,17 +112,58 @@ func (s *service) Login(input LoginInput) (User, error) {
 	email := input.Email
 	password := input.Password
 
+	if allowed, _ := s.loginLimiter.Allow(input.IPAddress); !allowed {
+		return User{}, ErrTooManyLogins
+	}
+
 	user, err := s.repository.FindByEmail(email)
+	if errors.Is(err, ErrUserNotFound) {
+		// Take as long as for a registered email and fail the same way, so
+		// logins cannot tell which emails have an account
+		s.hasher.Compare(s.dummyHash, password)
+		s.loginLimiter.Fail(input.IPAddress)
+
+		return User{}, ErrInvalidCredentials
+	}
 	if err != nil {
 		return user, err
 	}
 
-	if user.ID == 0 {
-		return user, errors.New("No user found on that email")
+	// A locked account fails like a wrong password, as an unknown email
+	// does, so the lockout does not give away that the account exists
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		s.hasher.Compare(s.dummyHash, password)
+		s.loginLimiter.Fail(input.IPAddress)
+
+		return User{}, ErrInvalidCredentials
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return user, err
+	if err := s.hasher.Compare(user.PasswordHash, password); err != nil {
+		s.loginLimiter.Fail(input.IPAddress)
+
+		if err := s.recordFailedLogin(user.ID); err != nil {
+			return User{}, err
+		}
+
+		return User{}, ErrInvalidCredentials
+	}
+
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := s.repository.ResetFailedLogins(user.ID); err != nil {
+			return user, err
+		}
+
+		user.FailedLogins = 0
+		user.LockedUntil = nil
+	}
+
+	// Upgrade hashes made with a weaker cost or another algorithm while the
+	// plain password is at hand
+	if s.hasher.NeedsRehash(user.PasswordHash) {
+		user, err = s.rehashPassword(user, password)
+		if err != nil {
+			log.Printf("failed to rehash password of user %d: %v", user.ID, err)
+		}
 	}
 
 	return user, nil
@@ -67,15 +172,14 @@ func (s *service) Login(input LoginInput) (User, error) {
 func (s *service) IsEmailAvailable(input CheckEmailInput) (bool, error) {
 	email := input.Email
 
-	user, err := s.repository.FindByEmail(email)
+	_, err := s.repository.FindByEmail(email)
+	if errors.Is(err, ErrUserNotFound) {
+		return true, nil
+	}
 	if err != nil {
 		return false, err
 	}
 
-	if user.ID == 0 {
-		return true, nil
-	}
-
 	return false, nil
 }
 
@@ -107,9 +211,657 @@ func (s *service) GetUserByID(id int) (User, error) {
 		return user, err
 	}
 
-	if user.ID == 0 {
-		return user, errors.New("No user found with that id")
+	return user, nil
+}
+
+func (s *service) UpdateProfile(id int, input UpdateProfileInput) (User, error) {
+	/**
+	 * 1. Get user by id
+	 * 2. Update name and occupation right away
+	 * 3. Keep a changed email aside until the new address is verified
+	 */
+
+	user, err := s.repository.FindByID(id)
+	if err != nil {
+		return user, err
+	}
+
+	user.Name = input.Name
+	user.Occupation = input.Occupation
+
+	emailChanged := input.Email != user.Email && input.Email != user.UnconfirmedEmail
+
+	if input.Email == user.Email {
+		user.UnconfirmedEmail = ""
+	} else if emailChanged {
+		isEmailAvailable, err := s.IsEmailAvailable(CheckEmailInput{Email: input.Email})
+		if err != nil {
+			return user, err
+		}
+
+		if !isEmailAvailable {
+			return user, ErrEmailRegistered
+		}
+
+		user.UnconfirmedEmail = input.Email
+	}
+
+	updatedUser, err := s.repository.Update(user)
+	if err != nil {
+		return updatedUser, err
+	}
+
+	if emailChanged {
+		if err := s.sendEmailVerification(updatedUser, updatedUser.UnconfirmedEmail); err != nil {
+			return updatedUser, err
+		}
+	}
+
+	return updatedUser, nil
+}
+
+func (s *service) ConfirmEmail(input ConfirmEmailInput) (User, error) {
+	userID, email, err := s.authService.ValidateEmailToken(input.Token)
+	if err != nil {
+		return User{}, ErrInvalidEmailToken
+	}
+
+	user, err := s.repository.FindByID(userID)
+	if errors.Is(err, ErrUserNotFound) {
+		return user, ErrInvalidEmailToken
+	}
+	if err != nil {
+		return user, err
+	}
+
+	switch {
+	case email == user.UnconfirmedEmail:
+		// The address might have been registered since the change was requested
+		isEmailAvailable, err := s.IsEmailAvailable(CheckEmailInput{Email: email})
+		if err != nil {
+			return user, err
+		}
+
+		if !isEmailAvailable {
+			return user, ErrEmailRegistered
+		}
+
+		user.Email = email
+		user.UnconfirmedEmail = ""
+	case email == user.Email && user.EmailVerifiedAt == nil:
+	default:
+		return user, ErrInvalidEmailToken
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+
+	updatedUser, err := s.repository.Update(user)
+	if err != nil {
+		return updatedUser, err
+	}
+
+	return updatedUser, nil
+}
+
+func (s *service) ResendEmailVerification(id int) error {
+	user, err := s.repository.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	if user.UnconfirmedEmail != "" {
+		return s.sendEmailVerification(user, user.UnconfirmedEmail)
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailVerified
+	}
+
+	return s.sendEmailVerification(user, user.Email)
+}
+
+func (s *service) sendEmailVerification(user User, email string) error {
+	token, err := s.authService.GenerateEmailToken(user.ID, email)
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.SendEmailVerification(user, email, token)
+}
+
+func (s *service) ChangePassword(id int, input ChangePasswordInput) (User, error) {
+	user, err := s.repository.FindByID(id)
+	if err != nil {
+		return user, err
+	}
+
+	if err := s.hasher.Compare(user.PasswordHash, input.CurrentPassword); err != nil {
+		return user, ErrWrongPassword
+	}
+
+	return s.setPassword(user, input.NewPassword)
+}
+
+func (s *service) RequestPasswordReset(input RequestPasswordResetInput) error {
+	user, err := s.repository.FindByEmail(input.Email)
+	// Unknown addresses are not reported, so the endpoint cannot be used to
+	// find out who has an account
+	if errors.Is(err, ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, tokenHash, err := generateResetToken()
+	if err != nil {
+		return err
+	}
+
+	passwordReset := PasswordReset{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(passwordResetLifetime),
+	}
+
+	if _, err := s.repository.SavePasswordReset(passwordReset); err != nil {
+		return err
+	}
+
+	return s.notifier.SendPasswordReset(user, token)
+}
+
+func (s *service) ResetPassword(input ResetPasswordInput) (User, error) {
+	passwordReset, err := s.repository.FindPasswordResetByTokenHash(hashToken(input.Token))
+	if err != nil {
+		return User{}, err
+	}
+
+	if passwordReset.UsedAt != nil || time.Now().After(passwordReset.ExpiresAt) {
+		return User{}, ErrInvalidPasswordResetToken
+	}
+
+	user, err := s.repository.FindByID(passwordReset.UserID)
+	if err != nil {
+		return user, err
+	}
+
+	passwordHash, err := s.hasher.Hash(input.NewPassword)
+	if err != nil {
+		return user, err
+	}
+
+	now := passwordChangeTime()
+	passwordReset.UsedAt = &now
+	user.PasswordHash = passwordHash
+	user.PasswordChangedAt = &now
+
+	return s.repository.ResetPassword(passwordReset, user)
+}
+
+// setPassword stores a new password. Bumping PasswordChangedAt invalidates
+// every token issued before.
+func (s *service) setPassword(user User, password string) (User, error) {
+	passwordHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return user, err
+	}
+
+	now := passwordChangeTime()
+	user.PasswordHash = passwordHash
+	user.PasswordChangedAt = &now
+
+	updatedUser, err := s.repository.Update(user)
+	if err != nil {
+		return updatedUser, err
+	}
+
+	return updatedUser, nil
+}
+
+func (s *service) UnlockUser(id int) (User, error) {
+	if _, err := s.repository.FindByID(id); err != nil {
+		return User{}, err
+	}
+
+	if err := s.repository.ResetFailedLogins(id); err != nil {
+		return User{}, err
+	}
+
+	return s.repository.FindByID(id)
+}
+
+// SetUpTwoFactor starts the enrolment by generating a new TOTP secret and
+// returning its provisioning URI. Two-factor authentication is enforced only
+// once EnableTwoFactor confirms the authenticator app works.
+func (s *service) SetUpTwoFactor(id int) (User, string, error) {
+	user, err := s.repository.FindByID(id)
+	if err != nil {
+		return user, "", err
+	}
+
+	if user.TwoFactorEnabledAt != nil {
+		return user, "", ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return user, "", err
+	}
+
+	user.TOTPSecret = secret
+
+	updatedUser, err := s.repository.Update(user)
+	if err != nil {
+		return updatedUser, "", err
+	}
+
+	return updatedUser, totp.ProvisioningURI(totpIssuer, updatedUser.Email, secret), nil
+}
+
+// EnableTwoFactor confirms the enrolment with a code from the authenticator
+// app and returns the recovery codes, which are never shown again.
+func (s *service) EnableTwoFactor(id int, input TwoFactorCodeInput) ([]string, error) {
+	user, err := s.repository.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabledAt != nil {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotSetUp
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, input.Code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactor
+	}
+
+	codes, recoveryCodes, err := generateRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repository.SaveRecoveryCodes(user.ID, recoveryCodes); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user.TwoFactorEnabledAt = &now
+	user.TOTPLastStep = step
+
+	if _, err := s.repository.Update(user); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *service) DisableTwoFactor(id int, input TwoFactorCodeInput) (User, error) {
+	user, err := s.repository.FindByID(id)
+	if err != nil {
+		return user, err
+	}
+
+	if user.TwoFactorEnabledAt == nil {
+		return user, ErrTwoFactorNotEnabled
+	}
+
+	user, err = s.checkSecondFactor(user, input.Code)
+	if err != nil {
+		return user, err
+	}
+
+	if err := s.repository.SaveRecoveryCodes(user.ID, nil); err != nil {
+		return user, err
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	user.TwoFactorEnabledAt = nil
+
+	updatedUser, err := s.repository.Update(user)
+	if err != nil {
+		return updatedUser, err
+	}
+
+	return updatedUser, nil
+}
+
+// VerifyTwoFactor completes a login started with Login. Failures count
+// towards the same lockout as wrong passwords.
+func (s *service) VerifyTwoFactor(input VerifyTwoFactorInput) (User, error) {
+	if allowed, _ := s.loginLimiter.Allow(input.IPAddress); !allowed {
+		return User{}, ErrTooManyLogins
+	}
+
+	userID, err := s.authService.ValidateTwoFactorToken(input.Token)
+	if err != nil {
+		return User{}, ErrInvalidTwoFactor
+	}
+
+	user, err := s.repository.FindByID(userID)
+	if err != nil {
+		return user, err
+	}
+
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		return User{}, ErrTooManyLogins
+	}
+
+	if user.TwoFactorEnabledAt == nil {
+		return User{}, ErrTwoFactorNotEnabled
+	}
+
+	user, err = s.checkSecondFactor(user, input.Code)
+	if errors.Is(err, ErrInvalidTwoFactor) {
+		s.loginLimiter.Fail(input.IPAddress)
+
+		if err := s.recordFailedLogin(user.ID); err != nil {
+			return User{}, err
+		}
+
+		return User{}, ErrInvalidTwoFactor
+	}
+	if err != nil {
+		return user, err
+	}
+
+	return user, nil
+}
+
+// checkSecondFactor accepts either a current TOTP code or an unused recovery
+// code, which is used up.
+func (s *service) checkSecondFactor(user User, code string) (User, error) {
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now())
+	if ok {
+		// A code stays valid for its whole period, never accept it twice
+		advanced, err := s.repository.AdvanceTOTPStep(user.ID, step)
+		if err != nil {
+			return user, err
+		}
+		if !advanced {
+			return user, ErrInvalidTwoFactor
+		}
+
+		user.TOTPLastStep = step
+
+		return user, nil
+	}
+
+	recoveryCodes, err := s.repository.FindRecoveryCodes(user.ID)
+	if err != nil {
+		return user, err
+	}
+
+	codeHash := hashToken(normalizeRecoveryCode(code))
+
+	for _, recoveryCode := range recoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(recoveryCode.CodeHash), []byte(codeHash)) != 1 {
+			continue
+		}
+
+		// A parallel request may have used the code in the meantime
+		used, err := s.repository.UseRecoveryCode(recoveryCode.ID, time.Now())
+		if err != nil {
+			return user, err
+		}
+		if !used {
+			return user, ErrInvalidTwoFactor
+		}
+
+		return user, nil
+	}
+
+	return user, ErrInvalidTwoFactor
+}
+
+// LoginWithProvider logs in the user linked to the provider identity. The
+// first time, the identity is linked to the user with the same email, or to
+// a new user when there is none, as long as the provider verified the email.
+func (s *service) LoginWithProvider(input ProviderLoginInput) (User, error) {
+	identity, err := s.repository.FindIdentity(input.Provider, input.Subject)
+	if err == nil {
+		return s.repository.FindByID(identity.UserID)
+	}
+	if !errors.Is(err, ErrIdentityNotFound) {
+		return User{}, err
+	}
+
+	if input.Email == "" || !input.EmailVerified {
+		return User{}, ErrProviderEmailNotVerified
+	}
+
+	user, err := s.repository.FindByEmail(input.Email)
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		user, err = s.registerProviderUser(input)
+	case err == nil && user.EmailVerifiedAt == nil:
+		// Nobody has proven owning the address before, so whoever chose the
+		// password may not be its owner. Replacing the password signs them out.
+		user, err = s.claimUser(user)
+	}
+	if err != nil {
+		return User{}, err
+	}
+
+	_, err = s.repository.SaveIdentity(Identity{
+		UserID:   user.ID,
+		Provider: input.Provider,
+		Subject:  input.Subject,
+		Email:    input.Email,
+	})
+	if err != nil {
+		return User{}, err
 	}
 
 	return user, nil
 }
+
+// registerProviderUser creates a user with a verified email and a random
+// password, which the user can replace through a password reset.
+func (s *service) registerProviderUser(input ProviderLoginInput) (User, error) {
+	password, _, err := generateResetToken()
+	if err != nil {
+		return User{}, err
+	}
+
+	passwordHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return User{}, err
+	}
+
+	now := time.Now()
+
+	user := User{}
+	user.Name = input.Name
+	user.Email = input.Email
+	user.EmailVerifiedAt = &now
+	user.PasswordHash = passwordHash
+	user.Role = "user"
+
+	if user.Name == "" {
+		user.Name = strings.Split(input.Email, "@")[0]
+	}
+
+	return s.repository.Save(user)
+}
+
+func (s *service) claimUser(user User) (User, error) {
+	password, _, err := generateResetToken()
+	if err != nil {
+		return user, err
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+
+	return s.setPassword(user, password)
+}
+
+func (s *service) GetIdentities(userID int) ([]Identity, error) {
+	identities, err := s.repository.FindIdentities(userID)
+	if err != nil {
+		return identities, err
+	}
+
+	return identities, nil
+}
+
+// DeleteAccount anonymises the user rather than deleting the row, so the
+// transactions that reference it stay intact for accounting.
+func (s *service) DeleteAccount(id int, input DeleteAccountInput) (User, error) {
+	user, err := s.repository.FindByID(id)
+	if err != nil {
+		return user, err
+	}
+
+	switch {
+	case input.Password != "":
+		if err := s.hasher.Compare(user.PasswordHash, input.Password); err != nil {
+			return user, ErrWrongPassword
+		}
+	case input.Code != "" && user.TwoFactorEnabledAt != nil:
+		if user, err = s.checkSecondFactor(user, input.Code); err != nil {
+			return user, err
+		}
+	case time.Since(input.LoggedInAt) > recentLoginWindow:
+		return user, ErrReauthenticate
+	}
+
+	now := time.Now()
+
+	user.Name = "Deleted user"
+	user.Occupation = ""
+	// Frees the address for a new account while keeping the column unique
+	user.Email = fmt.Sprintf("deleted-%d@users.invalid", user.ID)
+	user.EmailVerifiedAt = nil
+	user.UnconfirmedEmail = ""
+	user.PasswordHash = ""
+	user.PasswordChangedAt = &now
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	user.TwoFactorEnabledAt = nil
+	user.AvatarFileName = ""
+	user.DeletedAt = &now
+
+	deletedUser, err := s.repository.Anonymize(user)
+	if err != nil {
+		return deletedUser, err
+	}
+
+	return deletedUser, nil
+}
+
+// recordFailedLogin counts the failure and locks the account once there
+// were too many in a row. The database increments the count, so parallel
+// attempts all count.
+func (s *service) recordFailedLogin(id int) error {
+	user, err := s.repository.IncrementFailedLogins(id)
+	if err != nil {
+		return err
+	}
+
+	if user.FailedLogins < maxFailedLogins {
+		return nil
+	}
+
+	return s.repository.LockUntil(user.ID, time.Now().Add(lockoutDuration(user.FailedLogins)))
+}
+
+// lockoutDuration doubles the lockout with every failure past
+// maxFailedLogins, up to maxLockout.
+func lockoutDuration(failedLogins int) time.Duration {
+	exponent := failedLogins - maxFailedLogins
+	if exponent >= 6 {
+		return maxLockout
+	}
+
+	lockout := baseLockout << exponent
+	if lockout > maxLockout {
+		return maxLockout
+	}
+
+	return lockout
+}
+
+// rehashPassword replaces the stored hash without touching
+// PasswordChangedAt, the password itself stays the same.
+func (s *service) rehashPassword(user User, password string) (User, error) {
+	passwordHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return user, err
+	}
+
+	user.PasswordHash = passwordHash
+
+	updatedUser, err := s.repository.Update(user)
+	if err != nil {
+		return user, err
+	}
+
+	return updatedUser, nil
+}
+
+// passwordChangeTime returns the current time at the millisecond precision
+// of session tokens and of the password_changed_at column. Truncating keeps
+// the database from rounding it up past a token issued right after.
+func passwordChangeTime() time.Time {
+	return time.Now().Truncate(time.Millisecond)
+}
+
+// generateResetToken returns a random token for the user and the hash of it
+// which is stored in the database.
+func generateResetToken() (string, string, error) {
+	tokenInByte := make([]byte, 32)
+	if _, err := rand.Read(tokenInByte); err != nil {
+		return "", "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(tokenInByte)
+
+	return token, hashToken(token), nil
+}
+
+// hashToken hashes high entropy secrets for storage. Unlike passwords they
+// need no slow hash.
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// generateRecoveryCodes returns the codes for the user, formatted as
+// "xxxxx-xxxxx", and their hashed records.
+func generateRecoveryCodes(userID int) ([]string, []RecoveryCode, error) {
+	var codes []string
+	var recoveryCodes []RecoveryCode
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		codeInByte := make([]byte, 7)
+		if _, err := rand.Read(codeInByte); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(codeInByte))[:10]
+		code = code[:5] + "-" + code[5:]
+
+		codes = append(codes, code)
+		recoveryCodes = append(recoveryCodes, RecoveryCode{
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	return codes, recoveryCodes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
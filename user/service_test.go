@@ -0,0 +1,746 @@
+package user
+
+import (
+	"backer/auth"
+	"backer/throttle"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRepository keeps users in memory. Like the database, it finds no
+// deleted users.
+type fakeRepository struct {
+	Repository
+	users          map[int]User
+	passwordResets map[int]PasswordReset
+	recoveryCodes  map[int]RecoveryCode
+}
+
+func newFakeRepository(users ...User) *fakeRepository {
+	r := &fakeRepository{
+		users:          map[int]User{},
+		passwordResets: map[int]PasswordReset{},
+		recoveryCodes:  map[int]RecoveryCode{},
+	}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+
+	return r
+}
+
+func (r *fakeRepository) FindByEmail(email string) (User, error) {
+	for _, user := range r.users {
+		if user.Email == email && user.DeletedAt == nil {
+			return user, nil
+		}
+	}
+
+	return User{}, ErrUserNotFound
+}
+
+func (r *fakeRepository) FindByID(id int) (User, error) {
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt != nil {
+		return User{}, ErrUserNotFound
+	}
+
+	return user, nil
+}
+
+func (r *fakeRepository) Update(user User) (User, error) {
+	r.users[user.ID] = user
+
+	return user, nil
+}
+
+func (r *fakeRepository) IncrementFailedLogins(id int) (User, error) {
+	user := r.users[id]
+	user.FailedLogins++
+	r.users[id] = user
+
+	return user, nil
+}
+
+func (r *fakeRepository) LockUntil(id int, lockedUntil time.Time) error {
+	user := r.users[id]
+	user.LockedUntil = &lockedUntil
+	r.users[id] = user
+
+	return nil
+}
+
+func (r *fakeRepository) ResetFailedLogins(id int) error {
+	user := r.users[id]
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+	r.users[id] = user
+
+	return nil
+}
+
+func (r *fakeRepository) SavePasswordReset(passwordReset PasswordReset) (PasswordReset, error) {
+	passwordReset.ID = len(r.passwordResets) + 1
+	r.passwordResets[passwordReset.ID] = passwordReset
+
+	return passwordReset, nil
+}
+
+func (r *fakeRepository) FindPasswordResetByTokenHash(tokenHash string) (PasswordReset, error) {
+	for _, passwordReset := range r.passwordResets {
+		if passwordReset.TokenHash == tokenHash {
+			return passwordReset, nil
+		}
+	}
+
+	return PasswordReset{}, ErrInvalidPasswordResetToken
+}
+
+// ResetPassword uses up the reset only if it is unused, as the conditional
+// update of the database does.
+func (r *fakeRepository) ResetPassword(passwordReset PasswordReset, user User) (User, error) {
+	if r.passwordResets[passwordReset.ID].UsedAt != nil {
+		return user, ErrInvalidPasswordResetToken
+	}
+
+	r.passwordResets[passwordReset.ID] = passwordReset
+	r.users[user.ID] = user
+
+	return user, nil
+}
+
+func (r *fakeRepository) SaveRecoveryCodes(userID int, recoveryCodes []RecoveryCode) error {
+	for ID, recoveryCode := range r.recoveryCodes {
+		if recoveryCode.UserID == userID {
+			delete(r.recoveryCodes, ID)
+		}
+	}
+
+	for _, recoveryCode := range recoveryCodes {
+		recoveryCode.ID = len(r.recoveryCodes) + 1
+		r.recoveryCodes[recoveryCode.ID] = recoveryCode
+	}
+
+	return nil
+}
+
+// FindRecoveryCodes finds the unused codes of the user.
+func (r *fakeRepository) FindRecoveryCodes(userID int) ([]RecoveryCode, error) {
+	var recoveryCodes []RecoveryCode
+	for _, recoveryCode := range r.recoveryCodes {
+		if recoveryCode.UserID == userID && recoveryCode.UsedAt == nil {
+			recoveryCodes = append(recoveryCodes, recoveryCode)
+		}
+	}
+
+	return recoveryCodes, nil
+}
+
+func (r *fakeRepository) UseRecoveryCode(id int, usedAt time.Time) (bool, error) {
+	recoveryCode := r.recoveryCodes[id]
+	if recoveryCode.UsedAt != nil {
+		return false, nil
+	}
+
+	recoveryCode.UsedAt = &usedAt
+	r.recoveryCodes[id] = recoveryCode
+
+	return true, nil
+}
+
+func (r *fakeRepository) AdvanceTOTPStep(id int, step int64) (bool, error) {
+	user := r.users[id]
+	if user.TOTPLastStep >= step {
+		return false, nil
+	}
+
+	user.TOTPLastStep = step
+	r.users[id] = user
+
+	return true, nil
+}
+
+func (r *fakeRepository) Anonymize(user User) (User, error) {
+	r.users[user.ID] = user
+
+	return user, nil
+}
+
+// staleResetRepository finds every reset unused, as a request racing
+// another one using the same token would.
+type staleResetRepository struct {
+	*fakeRepository
+}
+
+func (r staleResetRepository) FindPasswordResetByTokenHash(tokenHash string) (PasswordReset, error) {
+	passwordReset, err := r.fakeRepository.FindPasswordResetByTokenHash(tokenHash)
+	passwordReset.UsedAt = nil
+
+	return passwordReset, err
+}
+
+// fakeNotifier keeps the last token sent instead of mailing it.
+type fakeNotifier struct {
+	token string
+}
+
+func (n *fakeNotifier) SendEmailVerification(user User, email string, token string) error {
+	n.token = token
+
+	return nil
+}
+
+func (n *fakeNotifier) SendPasswordReset(user User, token string) error {
+	n.token = token
+
+	return nil
+}
+
+// countingHasher counts the comparisons, so tests can tell a login took as
+// long as checking a real password.
+type countingHasher struct {
+	PasswordHasher
+	compares int
+}
+
+func (h *countingHasher) Compare(hash string, password string) error {
+	h.compares++
+
+	return h.PasswordHasher.Compare(hash, password)
+}
+
+const testPassword = "correct horse"
+
+func newTestUser(t *testing.T, hasher PasswordHasher) User {
+	t.Helper()
+
+	passwordHash, err := hasher.Hash(testPassword)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+
+	return User{ID: 1, Name: "Alice", Email: "alice@example.com", PasswordHash: passwordHash, Role: "user"}
+}
+
+func newTestService(repository Repository, hasher PasswordHasher) *service {
+	return NewService(repository, nil, nil, hasher, throttle.NewLimiter(100, time.Minute, time.Hour))
+}
+
+func TestLoginFailsUniformly(t *testing.T) {
+	now := time.Now()
+	lockedUntil := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		modify   func(user *User)
+	}{
+		{"unknown email", "bob@example.com", testPassword, func(user *User) {}},
+		{"wrong password", "alice@example.com", "wrong password", func(user *User) {}},
+		{"locked account", "alice@example.com", testPassword, func(user *User) { user.LockedUntil = &lockedUntil }},
+		{"deleted account", "alice@example.com", testPassword, func(user *User) { user.DeletedAt = &now }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hasher := &countingHasher{PasswordHasher: NewBcryptHasher(4)}
+			user := newTestUser(t, hasher)
+			test.modify(&user)
+
+			s := newTestService(newFakeRepository(user), hasher)
+
+			_, err := s.Login(LoginInput{Email: test.email, Password: test.password, IPAddress: "192.0.2.1"})
+			if !errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("Login() error = %v, want %v", err, ErrInvalidCredentials)
+			}
+
+			if hasher.compares != 1 {
+				t.Errorf("Login() compared %d hashes, want 1", hasher.compares)
+			}
+		})
+	}
+}
+
+func TestLoginLocksAccount(t *testing.T) {
+	hasher := NewBcryptHasher(4)
+	repository := newFakeRepository(newTestUser(t, hasher))
+	s := newTestService(repository, hasher)
+
+	login := func(password string) error {
+		_, err := s.Login(LoginInput{Email: "alice@example.com", Password: password, IPAddress: "192.0.2.1"})
+
+		return err
+	}
+
+	for i := 0; i < maxFailedLogins; i++ {
+		if err := login("wrong password"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("Login() error = %v, want %v", err, ErrInvalidCredentials)
+		}
+	}
+
+	if repository.users[1].LockedUntil == nil {
+		t.Fatalf("account is not locked after %d failures", maxFailedLogins)
+	}
+
+	if err := login(testPassword); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Login() of a locked account error = %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	// Let the lockout run out
+	expired := time.Now().Add(-time.Second)
+	user := repository.users[1]
+	user.LockedUntil = &expired
+	repository.users[1] = user
+
+	if err := login(testPassword); err != nil {
+		t.Fatalf("Login() after the lockout error = %v", err)
+	}
+
+	if user := repository.users[1]; user.FailedLogins != 0 || user.LockedUntil != nil {
+		t.Errorf("Login() left FailedLogins = %d, LockedUntil = %v", user.FailedLogins, user.LockedUntil)
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+	hasher := NewBcryptHasher(4)
+	lockedUntil := time.Now().Add(time.Hour)
+
+	user := newTestUser(t, hasher)
+	user.FailedLogins = maxFailedLogins
+	user.LockedUntil = &lockedUntil
+
+	s := newTestService(newFakeRepository(user), hasher)
+
+	unlockedUser, err := s.UnlockUser(user.ID)
+	if err != nil {
+		t.Fatalf("UnlockUser() error = %v", err)
+	}
+
+	if unlockedUser.FailedLogins != 0 || unlockedUser.LockedUntil != nil {
+		t.Errorf("UnlockUser() = FailedLogins %d, LockedUntil %v", unlockedUser.FailedLogins, unlockedUser.LockedUntil)
+	}
+
+	if _, err := s.Login(LoginInput{Email: user.Email, Password: testPassword, IPAddress: "192.0.2.1"}); err != nil {
+		t.Errorf("Login() after unlocking error = %v", err)
+	}
+}
+
+func TestLoginLimitsIPAddress(t *testing.T) {
+	hasher := NewBcryptHasher(4)
+	s := NewService(newFakeRepository(newTestUser(t, hasher)), nil, nil, hasher, throttle.NewLimiter(2, time.Minute, time.Hour))
+
+	// Different accounts, the limit is per address
+	for _, email := range []string{"alice@example.com", "bob@example.com"} {
+		if _, err := s.Login(LoginInput{Email: email, Password: "wrong password", IPAddress: "192.0.2.1"}); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("Login() error = %v, want %v", err, ErrInvalidCredentials)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		ipAddress string
+		want      error
+	}{
+		{"blocked address", "192.0.2.1", ErrTooManyLogins},
+		{"other address", "192.0.2.2", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := s.Login(LoginInput{Email: "alice@example.com", Password: testPassword, IPAddress: test.ipAddress})
+			if !errors.Is(err, test.want) {
+				t.Errorf("Login() error = %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		failedLogins int
+		want         time.Duration
+	}{
+		{maxFailedLogins, baseLockout},
+		{maxFailedLogins + 1, 2 * baseLockout},
+		{maxFailedLogins + 3, 8 * baseLockout},
+		{maxFailedLogins + 6, maxLockout},
+		{maxFailedLogins + 100, maxLockout},
+	}
+
+	for _, test := range tests {
+		if got := lockoutDuration(test.failedLogins); got != test.want {
+			t.Errorf("lockoutDuration(%d) = %v, want %v", test.failedLogins, got, test.want)
+		}
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	usedAt := time.Now()
+
+	tests := []struct {
+		name   string
+		modify func(repository *fakeRepository, token *string)
+		stale  bool
+		want   error
+	}{
+		{
+			name:   "unused token",
+			modify: func(repository *fakeRepository, token *string) {},
+			want:   nil,
+		},
+		{
+			name: "used token",
+			modify: func(repository *fakeRepository, token *string) {
+				passwordReset := repository.passwordResets[1]
+				passwordReset.UsedAt = &usedAt
+				repository.passwordResets[1] = passwordReset
+			},
+			want: ErrInvalidPasswordResetToken,
+		},
+		{
+			name: "used by a parallel request",
+			modify: func(repository *fakeRepository, token *string) {
+				passwordReset := repository.passwordResets[1]
+				passwordReset.UsedAt = &usedAt
+				repository.passwordResets[1] = passwordReset
+			},
+			stale: true,
+			want:  ErrInvalidPasswordResetToken,
+		},
+		{
+			name: "expired token",
+			modify: func(repository *fakeRepository, token *string) {
+				passwordReset := repository.passwordResets[1]
+				passwordReset.ExpiresAt = time.Now().Add(-time.Second)
+				repository.passwordResets[1] = passwordReset
+			},
+			want: ErrInvalidPasswordResetToken,
+		},
+		{
+			name:   "unknown token",
+			modify: func(repository *fakeRepository, token *string) { *token += "x" },
+			want:   ErrInvalidPasswordResetToken,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hasher := NewBcryptHasher(4)
+			user := newTestUser(t, hasher)
+			repository := newFakeRepository(user)
+			notifier := &fakeNotifier{}
+
+			s := newTestService(repository, hasher)
+			s.notifier = notifier
+
+			if err := s.RequestPasswordReset(RequestPasswordResetInput{Email: user.Email}); err != nil {
+				t.Fatalf("RequestPasswordReset() error = %v", err)
+			}
+
+			token := notifier.token
+			test.modify(repository, &token)
+
+			if test.stale {
+				s.repository = staleResetRepository{repository}
+			}
+
+			_, err := s.ResetPassword(ResetPasswordInput{Token: token, NewPassword: "new password"})
+			if !errors.Is(err, test.want) {
+				t.Fatalf("ResetPassword() error = %v, want %v", err, test.want)
+			}
+
+			_, err = s.Login(LoginInput{Email: user.Email, Password: "new password", IPAddress: "192.0.2.1"})
+			if changed := err == nil; changed != (test.want == nil) {
+				t.Errorf("password changed = %v, want %v", changed, test.want == nil)
+			}
+		})
+	}
+}
+
+func TestResetPasswordIsSingleUse(t *testing.T) {
+	hasher := NewBcryptHasher(4)
+	user := newTestUser(t, hasher)
+	notifier := &fakeNotifier{}
+
+	s := newTestService(newFakeRepository(user), hasher)
+	s.notifier = notifier
+
+	if err := s.RequestPasswordReset(RequestPasswordResetInput{Email: user.Email}); err != nil {
+		t.Fatalf("RequestPasswordReset() error = %v", err)
+	}
+
+	if _, err := s.ResetPassword(ResetPasswordInput{Token: notifier.token, NewPassword: "new password"}); err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+
+	_, err := s.ResetPassword(ResetPasswordInput{Token: notifier.token, NewPassword: "another password"})
+	if !errors.Is(err, ErrInvalidPasswordResetToken) {
+		t.Errorf("second ResetPassword() error = %v, want %v", err, ErrInvalidPasswordResetToken)
+	}
+}
+
+// totpCode computes the code an authenticator app shows for secret at t.
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("DecodeString() error = %v", err)
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(at.Unix()/30))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff
+
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+// newTwoFactorUser enrols the test user in two-factor authentication and
+// returns the recovery codes.
+func newTwoFactorUser(t *testing.T, s *service, repository *fakeRepository) (User, []string) {
+	t.Helper()
+
+	user, _, err := s.SetUpTwoFactor(1)
+	if err != nil {
+		t.Fatalf("SetUpTwoFactor() error = %v", err)
+	}
+
+	// Enrol with the code of the previous period, so the current one is
+	// still unused
+	codes, err := s.EnableTwoFactor(user.ID, TwoFactorCodeInput{Code: totpCode(t, user.TOTPSecret, time.Now().Add(-30*time.Second))})
+	if err != nil {
+		t.Fatalf("EnableTwoFactor() error = %v", err)
+	}
+
+	return repository.users[user.ID], codes
+}
+
+func TestCheckSecondFactor(t *testing.T) {
+	tests := []struct {
+		name  string
+		codes func(t *testing.T, user User, recoveryCodes []string) []string
+		want  []error
+	}{
+		{
+			name: "TOTP code",
+			codes: func(t *testing.T, user User, recoveryCodes []string) []string {
+				return []string{totpCode(t, user.TOTPSecret, time.Now())}
+			},
+			want: []error{nil},
+		},
+		{
+			name: "replayed TOTP code",
+			codes: func(t *testing.T, user User, recoveryCodes []string) []string {
+				code := totpCode(t, user.TOTPSecret, time.Now())
+
+				return []string{code, code}
+			},
+			want: []error{nil, ErrInvalidTwoFactor},
+		},
+		{
+			name: "TOTP code accepted on enrolment",
+			codes: func(t *testing.T, user User, recoveryCodes []string) []string {
+				return []string{totpCode(t, user.TOTPSecret, time.Unix(user.TOTPLastStep*30, 0))}
+			},
+			want: []error{ErrInvalidTwoFactor},
+		},
+		{
+			name:  "recovery code",
+			codes: func(t *testing.T, user User, recoveryCodes []string) []string { return recoveryCodes[:1] },
+			want:  []error{nil},
+		},
+		{
+			name: "recovery code without dash in upper case",
+			codes: func(t *testing.T, user User, recoveryCodes []string) []string {
+				return []string{strings.ToUpper(strings.ReplaceAll(recoveryCodes[0], "-", ""))}
+			},
+			want: []error{nil},
+		},
+		{
+			name: "reused recovery code",
+			codes: func(t *testing.T, user User, recoveryCodes []string) []string {
+				return []string{recoveryCodes[0], recoveryCodes[1], recoveryCodes[0]}
+			},
+			want: []error{nil, nil, ErrInvalidTwoFactor},
+		},
+		{
+			name: "wrong code",
+			codes: func(t *testing.T, user User, recoveryCodes []string) []string {
+				return []string{"000000", "abcde-fghij"}
+			},
+			want: []error{ErrInvalidTwoFactor, ErrInvalidTwoFactor},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hasher := NewBcryptHasher(4)
+			repository := newFakeRepository(newTestUser(t, hasher))
+			s := newTestService(repository, hasher)
+
+			user, recoveryCodes := newTwoFactorUser(t, s, repository)
+
+			for i, code := range test.codes(t, user, recoveryCodes) {
+				_, err := s.checkSecondFactor(repository.users[user.ID], code)
+				if !errors.Is(err, test.want[i]) {
+					t.Errorf("checkSecondFactor() #%d error = %v, want %v", i+1, err, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyTwoFactorCountsFailures(t *testing.T) {
+	hasher := NewBcryptHasher(4)
+	repository := newFakeRepository(newTestUser(t, hasher))
+	authService := auth.NewService()
+
+	s := newTestService(repository, hasher)
+	s.authService = authService
+
+	user, _ := newTwoFactorUser(t, s, repository)
+
+	token, err := authService.GenerateTwoFactorToken(user.ID)
+	if err != nil {
+		t.Fatalf("GenerateTwoFactorToken() error = %v", err)
+	}
+
+	for i := 0; i < maxFailedLogins; i++ {
+		_, err := s.VerifyTwoFactor(VerifyTwoFactorInput{Token: token, Code: "000000", IPAddress: "192.0.2.1"})
+		if !errors.Is(err, ErrInvalidTwoFactor) {
+			t.Fatalf("VerifyTwoFactor() error = %v, want %v", err, ErrInvalidTwoFactor)
+		}
+	}
+
+	code := totpCode(t, user.TOTPSecret, time.Now())
+
+	_, err = s.VerifyTwoFactor(VerifyTwoFactorInput{Token: token, Code: code, IPAddress: "192.0.2.1"})
+	if !errors.Is(err, ErrTooManyLogins) {
+		t.Errorf("VerifyTwoFactor() of a locked account error = %v, want %v", err, ErrTooManyLogins)
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	recentLogin := time.Now().Add(-time.Minute)
+	staleLogin := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		twoFactor bool
+		input     func(t *testing.T, user User) DeleteAccountInput
+		want      error
+	}{
+		{
+			name: "password",
+			input: func(t *testing.T, user User) DeleteAccountInput {
+				return DeleteAccountInput{Password: testPassword, LoggedInAt: staleLogin}
+			},
+		},
+		{
+			name: "wrong password",
+			input: func(t *testing.T, user User) DeleteAccountInput {
+				return DeleteAccountInput{Password: "wrong password", LoggedInAt: recentLogin}
+			},
+			want: ErrWrongPassword,
+		},
+		{
+			name:      "two-factor code",
+			twoFactor: true,
+			input: func(t *testing.T, user User) DeleteAccountInput {
+				return DeleteAccountInput{Code: totpCode(t, user.TOTPSecret, time.Now()), LoggedInAt: staleLogin}
+			},
+		},
+		{
+			name:      "wrong two-factor code",
+			twoFactor: true,
+			input: func(t *testing.T, user User) DeleteAccountInput {
+				return DeleteAccountInput{Code: "000000", LoggedInAt: recentLogin}
+			},
+			want: ErrInvalidTwoFactor,
+		},
+		{
+			name: "code without two-factor authentication",
+			input: func(t *testing.T, user User) DeleteAccountInput {
+				return DeleteAccountInput{Code: "000000", LoggedInAt: staleLogin}
+			},
+			want: ErrReauthenticate,
+		},
+		{
+			name: "recent login",
+			input: func(t *testing.T, user User) DeleteAccountInput {
+				return DeleteAccountInput{LoggedInAt: recentLogin}
+			},
+		},
+		{
+			name: "stale login",
+			input: func(t *testing.T, user User) DeleteAccountInput {
+				return DeleteAccountInput{LoggedInAt: staleLogin}
+			},
+			want: ErrReauthenticate,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hasher := NewBcryptHasher(4)
+			user := newTestUser(t, hasher)
+			repository := newFakeRepository(user)
+			s := newTestService(repository, hasher)
+
+			if test.twoFactor {
+				user, _ = newTwoFactorUser(t, s, repository)
+			}
+
+			_, err := s.DeleteAccount(user.ID, test.input(t, user))
+			if !errors.Is(err, test.want) {
+				t.Fatalf("DeleteAccount() error = %v, want %v", err, test.want)
+			}
+
+			_, err = s.GetUserByID(user.ID)
+			if deleted := errors.Is(err, ErrUserNotFound); deleted != (test.want == nil) {
+				t.Errorf("user deleted = %v, want %v", deleted, test.want == nil)
+			}
+		})
+	}
+}
+
+func TestDeleteAccountScrubsUser(t *testing.T) {
+	hasher := NewBcryptHasher(4)
+	user := newTestUser(t, hasher)
+	user.AvatarFileName = "avatars/alice.png"
+
+	repository := newFakeRepository(user)
+	s := newTestService(repository, hasher)
+
+	if _, err := s.DeleteAccount(user.ID, DeleteAccountInput{Password: testPassword}); err != nil {
+		t.Fatalf("DeleteAccount() error = %v", err)
+	}
+
+	deletedUser := repository.users[user.ID]
+	if deletedUser.DeletedAt == nil || deletedUser.PasswordHash != "" || deletedUser.AvatarFileName != "" {
+		t.Errorf("DeleteAccount() kept %+v", deletedUser)
+	}
+
+	if available, _ := s.IsEmailAvailable(CheckEmailInput{Email: user.Email}); !available {
+		t.Error("email of a deleted user is not available")
+	}
+
+	_, err := s.Login(LoginInput{Email: user.Email, Password: testPassword, IPAddress: "192.0.2.1"})
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Login() of a deleted user error = %v, want %v", err, ErrInvalidCredentials)
+	}
+}